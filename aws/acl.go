@@ -0,0 +1,312 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/rs/zerolog/log"
+)
+
+type ACLMode string
+
+const (
+	// ACLModeEnabled sends ACLs with all writes and compares them for drift detection.
+	ACLModeEnabled ACLMode = "enabled"
+	// ACLModeDisabled never sends or reads ACLs.
+	ACLModeDisabled ACLMode = "disabled"
+	// ACLModeAuto disables ACLs if the bucket enforces the bucket owner or does not support ownership controls.
+	ACLModeAuto ACLMode = "auto"
+)
+
+// errCodeOwnershipControlsNotFound is returned for buckets without ownership controls, which accept ACLs.
+const errCodeOwnershipControlsNotFound = "OwnershipControlsNotFoundError"
+
+var ErrInvalidACLMode = errors.New("invalid acl mode")
+
+func (am *ACLMode) Set(value string) error {
+	switch ACLMode(value) {
+	case ACLModeEnabled, ACLModeDisabled, ACLModeAuto:
+		*am = ACLMode(value)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidACLMode, value)
+	}
+}
+
+func (am *ACLMode) String() string {
+	return string(*am)
+}
+
+// DetectACLMode reads the ownership controls of the bucket. ACLs are disabled if the bucket
+// owner is enforced or the store does not implement ownership controls.
+func (u *S3) DetectACLMode(ctx context.Context) (ACLMode, error) {
+	resp, err := u.client.GetBucketOwnershipControls(ctx, &s3.GetBucketOwnershipControlsInput{
+		Bucket: &u.Bucket,
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == errCodeOwnershipControlsNotFound {
+			return ACLModeEnabled, nil
+		}
+
+		if !notSupported(err) {
+			return "", err
+		}
+
+		log.Debug().Msgf("ownership controls not supported, disabling ACLs: %v", err)
+
+		return ACLModeDisabled, nil
+	}
+
+	if resp.OwnershipControls != nil {
+		for _, rule := range resp.OwnershipControls.Rules {
+			if rule.ObjectOwnership == types.ObjectOwnershipBucketOwnerEnforced {
+				return ACLModeDisabled, nil
+			}
+		}
+	}
+
+	return ACLModeEnabled, nil
+}
+
+// aclEnabled reports whether ACLs are sent and compared.
+func (u *S3) aclEnabled() bool {
+	return u.ACLMode != ACLModeDisabled
+}
+
+// cannedACL returns the ACL to send, which is empty if ACLs are disabled.
+func (u *S3) cannedACL(acl string) types.ObjectCannedACL {
+	if !u.aclEnabled() {
+		return ""
+	}
+
+	return types.ObjectCannedACL(acl)
+}
+
+type ACLErrorPolicy string
+
+const (
+	// ACLErrorPolicyIgnore treats the ACL as unchanged if it cannot be read.
+	ACLErrorPolicyIgnore ACLErrorPolicy = "ignore"
+	// ACLErrorPolicyWarn logs a warning and treats the ACL as unchanged if it cannot be read.
+	ACLErrorPolicyWarn ACLErrorPolicy = "warn"
+	// ACLErrorPolicyFail fails the upload if the ACL cannot be read.
+	ACLErrorPolicyFail ACLErrorPolicy = "fail"
+)
+
+const (
+	groupAllUsers           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	groupAuthenticatedUsers = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+	// awsExecReadID is the canonical user that is granted read access by the aws-exec-read ACL.
+	awsExecReadID = "6aa5a366c34c1cbe25dc49211496e913e0351eb0e8c37aa3477e40942ec6b97c"
+	// customACL describes grants that do not match any canned ACL.
+	customACL = "custom"
+)
+
+var (
+	ErrInvalidACLErrorPolicy = errors.New("invalid acl error policy")
+	ErrACLLookup             = errors.New("failed to read acl")
+)
+
+// cannedACLs are tried in order to name the current grants of an object.
+var cannedACLs = []types.ObjectCannedACL{
+	types.ObjectCannedACLPrivate,
+	types.ObjectCannedACLPublicRead,
+	types.ObjectCannedACLPublicReadWrite,
+	types.ObjectCannedACLAuthenticatedRead,
+	types.ObjectCannedACLAwsExecRead,
+	types.ObjectCannedACLBucketOwnerRead,
+	types.ObjectCannedACLBucketOwnerFullControl,
+}
+
+func (ap *ACLErrorPolicy) Set(value string) error {
+	switch ACLErrorPolicy(value) {
+	case ACLErrorPolicyIgnore, ACLErrorPolicyWarn, ACLErrorPolicyFail:
+		*ap = ACLErrorPolicy(value)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidACLErrorPolicy, value)
+	}
+}
+
+func (ap *ACLErrorPolicy) String() string {
+	return string(*ap)
+}
+
+// grant is a single permission of a grantee, which is either a canonical user or a group.
+type grant struct {
+	id         string
+	uri        string
+	permission types.Permission
+}
+
+type grantSet map[grant]bool
+
+func newGrantSet(grants []types.Grant) grantSet {
+	set := make(grantSet, len(grants))
+
+	for _, g := range grants {
+		if g.Grantee == nil {
+			continue
+		}
+
+		set[grant{id: aws.ToString(g.Grantee.ID), uri: aws.ToString(g.Grantee.URI), permission: g.Permission}] = true
+	}
+
+	return set
+}
+
+// cannedGrants returns the grants S3 assigns for the canned ACL. The object owner always gets full
+// control, a bucket owner that also owns the object gets no additional grant. False is returned
+// for unknown ACLs.
+func cannedGrants(acl types.ObjectCannedACL, owner, bucketOwner string) (grantSet, bool) {
+	set := grantSet{{id: owner, permission: types.PermissionFullControl}: true}
+
+	switch acl {
+	case types.ObjectCannedACLPrivate:
+	case types.ObjectCannedACLPublicRead:
+		set[grant{uri: groupAllUsers, permission: types.PermissionRead}] = true
+	case types.ObjectCannedACLPublicReadWrite:
+		set[grant{uri: groupAllUsers, permission: types.PermissionRead}] = true
+		set[grant{uri: groupAllUsers, permission: types.PermissionWrite}] = true
+	case types.ObjectCannedACLAuthenticatedRead:
+		set[grant{uri: groupAuthenticatedUsers, permission: types.PermissionRead}] = true
+	case types.ObjectCannedACLAwsExecRead:
+		set[grant{id: awsExecReadID, permission: types.PermissionRead}] = true
+	case types.ObjectCannedACLBucketOwnerRead:
+		if bucketOwner != owner {
+			set[grant{id: bucketOwner, permission: types.PermissionRead}] = true
+		}
+	case types.ObjectCannedACLBucketOwnerFullControl:
+		if bucketOwner != owner {
+			set[grant{id: bucketOwner, permission: types.PermissionFullControl}] = true
+		}
+	default:
+		return nil, false
+	}
+
+	return set, true
+}
+
+// equal reports whether both sets contain the same grants.
+func (s grantSet) equal(other grantSet) bool {
+	if len(s) != len(other) {
+		return false
+	}
+
+	for g := range s {
+		if !other[g] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// grantsOtherUser reports whether a canonical user other than the owner has a grant, which
+// is only the case for ACLs that depend on the bucket owner.
+func (s grantSet) grantsOtherUser(owner string) bool {
+	for g := range s {
+		if g.id != "" && g.id != owner && g.id != awsExecReadID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// name returns the canned ACL that results in the grants or custom if none does.
+func (s grantSet) name(owner, bucketOwner string) string {
+	for _, acl := range cannedACLs {
+		if expected, _ := cannedGrants(acl, owner, bucketOwner); s.equal(expected) {
+			return string(acl)
+		}
+	}
+
+	return customACL
+}
+
+// aclChanged compares the grants of the remote object with the grants of the canned ACL.
+// Errors reading the ACL are handled according to the ACL error policy.
+func (u *S3) aclChanged(ctx context.Context, remote, acl string) (bool, string, error) {
+	resp, err := u.client.GetObjectAcl(ctx, &s3.GetObjectAclInput{
+		Bucket: &u.Bucket,
+		Key:    &remote,
+	})
+	if err != nil {
+		return false, "", u.aclLookupFailed(remote, err)
+	}
+
+	var owner string
+	if resp.Owner != nil {
+		owner = aws.ToString(resp.Owner.ID)
+	}
+
+	current := newGrantSet(resp.Grants)
+
+	var bucketOwner string
+
+	desired := types.ObjectCannedACL(acl)
+	if desired == types.ObjectCannedACLBucketOwnerRead || desired == types.ObjectCannedACLBucketOwnerFullControl ||
+		current.grantsOtherUser(owner) {
+		if bucketOwner, err = u.bucketOwner(ctx); err != nil {
+			return false, "", u.aclLookupFailed(remote, err)
+		}
+	}
+
+	if expected, ok := cannedGrants(desired, owner, bucketOwner); ok && current.equal(expected) {
+		return false, "", nil
+	}
+
+	previousACL := current.name(owner, bucketOwner)
+	reason := fmt.Sprintf("permissions for '%s' have changed from '%s' to '%s'", remote, previousACL, acl)
+
+	return true, reason, nil
+}
+
+// aclLookupFailed applies the ACL error policy, the zero value warns.
+func (u *S3) aclLookupFailed(remote string, err error) error {
+	switch u.ACLErrorPolicy {
+	case ACLErrorPolicyIgnore:
+		return nil
+	case ACLErrorPolicyFail:
+		return fmt.Errorf("%w for '%s': %w", ErrACLLookup, remote, err)
+	default:
+		log.Warn().Msgf("unable to read acl of '%s', skipping permission check: %v", remote, err)
+
+		return nil
+	}
+}
+
+// bucketOwner returns the canonical user ID of the bucket owner, which is read once.
+func (u *S3) bucketOwner(ctx context.Context) (string, error) {
+	u.ownerMu.Lock()
+	defer u.ownerMu.Unlock()
+
+	if u.owner != nil {
+		return *u.owner, nil
+	}
+
+	resp, err := u.client.GetBucketAcl(ctx, &s3.GetBucketAclInput{
+		Bucket: &u.Bucket,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var owner string
+	if resp.Owner != nil {
+		owner = aws.ToString(resp.Owner.ID)
+	}
+
+	u.owner = &owner
+
+	return owner, nil
+}
@@ -0,0 +1,280 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+func TestS3_DetectACLMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		resp    *s3.GetBucketOwnershipControlsOutput
+		err     error
+		want    ACLMode
+		wantErr bool
+	}{
+		{
+			name: "bucket owner enforced",
+			resp: &s3.GetBucketOwnershipControlsOutput{
+				OwnershipControls: &types.OwnershipControls{
+					Rules: []types.OwnershipControlsRule{{ObjectOwnership: types.ObjectOwnershipBucketOwnerEnforced}},
+				},
+			},
+			want: ACLModeDisabled,
+		},
+		{
+			name: "bucket owner preferred",
+			resp: &s3.GetBucketOwnershipControlsOutput{
+				OwnershipControls: &types.OwnershipControls{
+					Rules: []types.OwnershipControlsRule{{ObjectOwnership: types.ObjectOwnershipBucketOwnerPreferred}},
+				},
+			},
+			want: ACLModeEnabled,
+		},
+		{
+			name: "no ownership controls",
+			resp: &s3.GetBucketOwnershipControlsOutput{},
+			err:  &smithy.GenericAPIError{Code: "OwnershipControlsNotFoundError"},
+			want: ACLModeEnabled,
+		},
+		{
+			name: "ownership controls not implemented",
+			resp: &s3.GetBucketOwnershipControlsOutput{},
+			err:  &smithy.GenericAPIError{Code: "NotImplemented"},
+			want: ACLModeDisabled,
+		},
+		{
+			name: "ownership controls method not allowed",
+			resp: &s3.GetBucketOwnershipControlsOutput{},
+			err:  &smithy.GenericAPIError{Code: "MethodNotAllowed"},
+			want: ACLModeDisabled,
+		},
+		{
+			name:    "error on access denied",
+			resp:    &s3.GetBucketOwnershipControlsOutput{},
+			err:     &smithy.GenericAPIError{Code: "AccessDenied"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			mockS3Client.On("GetBucketOwnershipControls", mock.Anything, mock.Anything).Return(tt.resp, tt.err)
+
+			got, err := (&S3{client: mockS3Client, Bucket: "test-bucket"}).DetectACLMode(t.Context())
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestS3_ACLModeDisabled(t *testing.T) {
+	t.Parallel()
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("PutObject", mock.Anything, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+		return input.ACL == ""
+	})).Return(&s3.PutObjectOutput{}, nil)
+
+	u := &S3{client: mockS3Client, Bucket: "test-bucket", ACLMode: ACLModeDisabled}
+
+	err := u.Redirect(t.Context(), S3RedirectOptions{Path: "old", Location: "/new"})
+	assert.NoError(t, err)
+
+	// GetObjectAcl is not expected by the mock and must not be called.
+	shouldCopy, _, err := u.shouldCopyObject(
+		t.Context(), &s3.HeadObjectOutput{ContentType: aws.String("text/plain")},
+		"file.txt", "file.txt", "text/plain", "public-read", "", "", map[string]string{},
+	)
+	assert.NoError(t, err)
+	assert.False(t, shouldCopy)
+}
+
+func TestS3_ACLChanged(t *testing.T) {
+	t.Parallel()
+
+	ownerGrant := func(id string, permission types.Permission) types.Grant {
+		return types.Grant{Grantee: &types.Grantee{ID: aws.String(id)}, Permission: permission}
+	}
+	groupGrant := func(uri string, permission types.Permission) types.Grant {
+		return types.Grant{Grantee: &types.Grantee{URI: aws.String(uri)}, Permission: permission}
+	}
+
+	tests := []struct {
+		name        string
+		acl         string
+		grants      []types.Grant
+		bucketOwner string
+		want        bool
+		wantReason  string
+	}{
+		{
+			name:   "unchanged private",
+			acl:    "private",
+			grants: []types.Grant{ownerGrant("owner", types.PermissionFullControl)},
+		},
+		{
+			name: "unchanged public-read-write",
+			acl:  "public-read-write",
+			grants: []types.Grant{
+				ownerGrant("owner", types.PermissionFullControl),
+				groupGrant(groupAllUsers, types.PermissionRead),
+				groupGrant(groupAllUsers, types.PermissionWrite),
+			},
+		},
+		{
+			name: "unchanged aws-exec-read",
+			acl:  "aws-exec-read",
+			grants: []types.Grant{
+				ownerGrant("owner", types.PermissionFullControl),
+				ownerGrant(awsExecReadID, types.PermissionRead),
+			},
+		},
+		{
+			name: "unchanged bucket-owner-full-control",
+			acl:  "bucket-owner-full-control",
+			grants: []types.Grant{
+				ownerGrant("owner", types.PermissionFullControl),
+				ownerGrant("bucket", types.PermissionFullControl),
+			},
+			bucketOwner: "bucket",
+		},
+		{
+			name:        "bucket-owner-read equals private for the bucket owner",
+			acl:         "bucket-owner-read",
+			grants:      []types.Grant{ownerGrant("owner", types.PermissionFullControl)},
+			bucketOwner: "owner",
+		},
+		{
+			name: "public-read changed to private",
+			acl:  "private",
+			grants: []types.Grant{
+				ownerGrant("owner", types.PermissionFullControl),
+				groupGrant(groupAllUsers, types.PermissionRead),
+			},
+			want:       true,
+			wantReason: "permissions for 'file.txt' have changed from 'public-read' to 'private'",
+		},
+		{
+			name: "bucket-owner-read changed to bucket-owner-full-control",
+			acl:  "bucket-owner-full-control",
+			grants: []types.Grant{
+				ownerGrant("owner", types.PermissionFullControl),
+				ownerGrant("bucket", types.PermissionRead),
+			},
+			bucketOwner: "bucket",
+			want:        true,
+			wantReason:  "permissions for 'file.txt' have changed from 'bucket-owner-read' to 'bucket-owner-full-control'",
+		},
+		{
+			name: "custom grants",
+			acl:  "public-read",
+			grants: []types.Grant{
+				ownerGrant("owner", types.PermissionFullControl),
+				groupGrant(groupAllUsers, types.PermissionReadAcp),
+			},
+			want:       true,
+			wantReason: "permissions for 'file.txt' have changed from 'custom' to 'public-read'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			mockS3Client.On("GetObjectAcl", mock.Anything, mock.Anything).Return(&s3.GetObjectAclOutput{
+				Owner:  &types.Owner{ID: aws.String("owner")},
+				Grants: tt.grants,
+			}, nil)
+
+			if tt.bucketOwner != "" {
+				mockS3Client.On("GetBucketAcl", mock.Anything, mock.Anything).Return(&s3.GetBucketAclOutput{
+					Owner: &types.Owner{ID: aws.String(tt.bucketOwner)},
+				}, nil).Once()
+			}
+
+			u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+
+			got, reason, err := u.aclChanged(t.Context(), "file.txt", tt.acl)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func TestS3_ACLErrorPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  ACLErrorPolicy
+		wantErr error
+	}{
+		{
+			name: "warn by default",
+		},
+		{
+			name:   "ignore error",
+			policy: ACLErrorPolicyIgnore,
+		},
+		{
+			name:    "fail on error",
+			policy:  ACLErrorPolicyFail,
+			wantErr: ErrACLLookup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			mockS3Client.On("GetObjectAcl", mock.Anything, mock.Anything).Return(
+				nil, &smithy.GenericAPIError{Code: "AccessDenied"},
+			)
+
+			u := &S3{client: mockS3Client, Bucket: "test-bucket", ACLErrorPolicy: tt.policy}
+
+			got, _, err := u.aclChanged(t.Context(), "file.txt", "private")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.False(t, got)
+		})
+	}
+}
+
+func TestACLErrorPolicy_Set(t *testing.T) {
+	t.Parallel()
+
+	var policy ACLErrorPolicy
+
+	assert.NoError(t, policy.Set("fail"))
+	assert.Equal(t, ACLErrorPolicyFail, policy)
+	assert.ErrorIs(t, policy.Set("invalid"), ErrInvalidACLErrorPolicy)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
@@ -22,6 +23,7 @@ func NewClient(
 	url, region, accessKey, secretKey string,
 	pathStyle bool,
 	cm string,
+	retryOpt RetryOptions,
 ) (*Client, error) {
 	var checksumMode ChecksumMode
 
@@ -34,7 +36,13 @@ func NewClient(
 		ChecksumRequired:  aws.RequestChecksumCalculationWhenRequired,
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	cfg, err := config.LoadDefaultConfig(
+		ctx,
+		config.WithRegion(region),
+		config.WithRetryer(func() aws.Retryer {
+			return newRetryer(retryOpt)
+		}),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("error while loading AWS config: %w", err)
 	}
@@ -48,14 +56,22 @@ func NewClient(
 		cfg.Credentials = credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")
 	}
 
-	c := s3.NewFromConfig(cfg, func(o *s3.Options) {
+	u := &S3{}
+	u.client = s3.NewFromConfig(cfg, func(o *s3.Options) {
 		o.UsePathStyle = pathStyle
 		o.RequestChecksumCalculation = checksumModeMap[checksumMode]
+
+		// The bandwidth limit is applied to the bytes sent on the wire.
+		if o.HTTPClient == nil {
+			o.HTTPClient = awshttp.NewBuildableClient()
+		}
+
+		o.HTTPClient = &bandwidthClient{client: o.HTTPClient, s3: u}
 	})
 	cf := cloudfront.NewFromConfig(cfg)
 
 	return &Client{
-		S3:         &S3{client: c},
+		S3:         u,
 		Cloudfront: &Cloudfront{client: cf},
 	}, nil
 }
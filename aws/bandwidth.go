@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// bandwidthChunkSize is the maximum number of bytes read from a limited body at once.
+const bandwidthChunkSize = 32 * 1024
+
+var ErrInvalidBandwidth = errors.New("invalid bandwidth")
+
+//nolint:gochecknoglobals
+var bandwidthUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+}
+
+// ParseBandwidth parses a bandwidth like `20MiB/s` or `500KB` into bytes per second.
+// An empty value returns zero, which disables the limit.
+func ParseBandwidth(value string) (int64, error) {
+	n, ok := parseBytes(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "/S"))
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidBandwidth, value)
+	}
+
+	return n, nil
+}
+
+// parseBytes parses a number of bytes with an optional decimal or binary unit.
+func parseBytes(value string) (int64, bool) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	if s == "" {
+		return 0, true
+	}
+
+	idx := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if idx < 0 {
+		idx = len(s)
+	}
+
+	num, err := strconv.ParseFloat(s[:idx], 64)
+	if err != nil || num <= 0 {
+		return 0, false
+	}
+
+	unit, ok := bandwidthUnits[strings.TrimSpace(s[idx:])]
+	if !ok {
+		return 0, false
+	}
+
+	// Values below one byte would disable the limit or result in empty parts.
+	n := int64(num * float64(unit))
+	if n < 1 {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// BandwidthLimiter is a token bucket shared by all concurrent transfers.
+type BandwidthLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// now and sleep are the clock of the bucket, they are replaced in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewBandwidthLimiter creates a limiter for the given bytes per second. A rate of zero
+// or less returns nil, which disables the limit.
+func NewBandwidthLimiter(bytesPerSecond int64) *BandwidthLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	rate := float64(bytesPerSecond)
+
+	return &BandwidthLimiter{
+		rate:   rate,
+		burst:  rate,
+		tokens: rate,
+		last:   time.Now(),
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+}
+
+// sleepContext waits for the duration or until the context is canceled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// WaitN blocks until n bytes may be transferred or the context is canceled.
+func (b *BandwidthLimiter) WaitN(ctx context.Context, n int) error {
+	for {
+		b.mu.Lock()
+
+		now := b.now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+
+		if b.tokens >= float64(n) {
+			b.tokens -= float64(n)
+			b.mu.Unlock()
+
+			return nil
+		}
+
+		wait := time.Duration((float64(n) - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := b.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// chunkSize returns the maximum read size that fits into the bucket.
+func (b *BandwidthLimiter) chunkSize() int {
+	return max(1, min(bandwidthChunkSize, int(b.burst)))
+}
+
+// limitedReader throttles reads of the wrapped reader with a bandwidth limiter.
+//
+//nolint:containedctx
+type limitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *BandwidthLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.chunkSize() {
+		p = p[:r.limiter.chunkSize()]
+	}
+
+	if err := r.limiter.WaitN(r.ctx, len(p)); err != nil {
+		return 0, err
+	}
+
+	return r.reader.Read(p)
+}
+
+// limitedReadCloser is a limited request body, the wrapped body is closed by the transport.
+type limitedReadCloser struct {
+	limitedReader
+	io.Closer
+}
+
+// bandwidthClient limits request bodies while they are sent. Bytes the SDK reads ahead to
+// compute checksums and payload hashes, and rewinds afterwards, are not charged.
+type bandwidthClient struct {
+	client s3.HTTPClient
+	s3     *S3
+}
+
+func (c *bandwidthClient) Do(req *http.Request) (*http.Response, error) {
+	if c.s3.Bandwidth != nil && req.Body != nil && req.Body != http.NoBody {
+		req.Body = &limitedReadCloser{
+			limitedReader: limitedReader{ctx: req.Context(), reader: req.Body, limiter: c.s3.Bandwidth},
+			Closer:        req.Body,
+		}
+	}
+
+	return c.client.Do(req)
+}
+
+// limitBody wraps a response body with the bandwidth limiter of the client.
+//
+//nolint:ireturn
+func (u *S3) limitBody(ctx context.Context, body io.Reader) io.Reader {
+	if u.Bandwidth == nil {
+		return body
+	}
+
+	return &limitedReader{ctx: ctx, reader: body, limiter: u.Bandwidth}
+}
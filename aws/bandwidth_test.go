@@ -0,0 +1,203 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr error
+	}{
+		{
+			name:  "empty value disables limit",
+			value: "",
+			want:  0,
+		},
+		{
+			name:  "plain bytes",
+			value: "1024",
+			want:  1024,
+		},
+		{
+			name:  "binary unit per second",
+			value: "20MiB/s",
+			want:  20 * 1024 * 1024,
+		},
+		{
+			name:  "decimal unit",
+			value: "500KB",
+			want:  500 * 1000,
+		},
+		{
+			name:  "fractional value",
+			value: "1.5 GiB/s",
+			want:  1536 * 1024 * 1024,
+		},
+		{
+			name:    "error on unknown unit",
+			value:   "20MBit/s",
+			wantErr: ErrInvalidBandwidth,
+		},
+		{
+			name:    "error on missing number",
+			value:   "MiB/s",
+			wantErr: ErrInvalidBandwidth,
+		},
+		{
+			name:    "error on negative number",
+			value:   "-1MiB",
+			wantErr: ErrInvalidBandwidth,
+		},
+		{
+			name:    "error on rate below one byte",
+			value:   "0.5B/s",
+			wantErr: ErrInvalidBandwidth,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseBandwidth(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// fakeClock advances its time when the bandwidth limiter sleeps instead of waiting.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	return nil
+}
+
+// newTestLimiter returns a bandwidth limiter that uses a fake clock.
+func newTestLimiter(bytesPerSecond int64) (*BandwidthLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	limiter := NewBandwidthLimiter(bytesPerSecond)
+	limiter.last = clock.now
+	limiter.now = clock.Now
+	limiter.sleep = clock.Sleep
+
+	return limiter, clock
+}
+
+func TestS3_LimitBody(t *testing.T) {
+	t.Parallel()
+
+	t.Run("return body unchanged without limit", func(t *testing.T) {
+		t.Parallel()
+
+		body := bytes.NewReader([]byte("hello"))
+		u := &S3{}
+
+		assert.Same(t, body, u.limitBody(t.Context(), body))
+	})
+
+	t.Run("throttle reads to configured rate", func(t *testing.T) {
+		t.Parallel()
+
+		limiter, clock := newTestLimiter(100 * 1024)
+		u := &S3{Bandwidth: limiter}
+		body := u.limitBody(t.Context(), bytes.NewReader(make([]byte, 150*1024)))
+
+		n, err := io.Copy(io.Discard, body)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(150*1024), n)
+		// The burst of 100KiB is read at once, the remaining 50KiB take at least half a second. Reads
+		// are charged with the size of the buffer, which includes the last read at the end of the body.
+		elapsed := clock.Now().Sub(time.Unix(0, 0))
+		assert.GreaterOrEqual(t, elapsed, 500*time.Millisecond)
+		assert.Less(t, elapsed, time.Second)
+	})
+
+	t.Run("abort read when context is canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		u := &S3{Bandwidth: NewBandwidthLimiter(1)}
+		body := u.limitBody(ctx, bytes.NewReader(make([]byte, 16)))
+
+		_, err := io.ReadAll(body)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestClient_Bandwidth(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(
+		t.Context(), server.URL, "us-east-1", "key", "secret", true, string(ChecksumRequired),
+		RetryOptions{MaxAttempts: 1},
+	)
+	assert.NoError(t, err)
+
+	client.S3.Bucket = "test-bucket"
+	client.S3.ChecksumAlgorithm = ChecksumAlgorithmSHA256
+	limiter, clock := newTestLimiter(100 * 1024)
+	client.S3.Bandwidth = limiter
+
+	err = client.S3.put(t.Context(), createLargeTempFile(t, 150*1024), &s3.PutObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("file.bin"),
+	}, nil)
+	assert.NoError(t, err)
+
+	// The body is hashed for the signature and the checksum before it is sent, only the bytes sent
+	// beyond the burst of 100KiB are throttled.
+	elapsed := clock.Now().Sub(time.Unix(0, 0))
+	assert.GreaterOrEqual(t, elapsed, 500*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+}
@@ -1,8 +1,18 @@
 package aws
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 type ChecksumMode string
@@ -28,3 +38,170 @@ func (cm *ChecksumMode) Set(value string) error {
 func (cm *ChecksumMode) String() string {
 	return string(*cm)
 }
+
+type ChecksumAlgorithm string
+
+const (
+	ChecksumAlgorithmCRC32C    ChecksumAlgorithm = "crc32c"
+	ChecksumAlgorithmCRC64NVME ChecksumAlgorithm = "crc64nvme"
+	ChecksumAlgorithmSHA256    ChecksumAlgorithm = "sha256"
+)
+
+// crc64NVME is the inverted NVME polynomial as required by crc64.MakeTable.
+const crc64NVME = 0x9a6c_9329_ac4b_c9b5
+
+var ErrInvalidChecksumAlgorithm = errors.New("invalid checksum algorithm")
+
+// Set validates the algorithm. An empty value disables additional checksums, changes
+// are detected by the MD5 based ETag in that case.
+func (ca *ChecksumAlgorithm) Set(value string) error {
+	switch ChecksumAlgorithm(value) {
+	case "", ChecksumAlgorithmCRC32C, ChecksumAlgorithmCRC64NVME, ChecksumAlgorithmSHA256:
+		*ca = ChecksumAlgorithm(value)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidChecksumAlgorithm, value)
+	}
+}
+
+func (ca *ChecksumAlgorithm) String() string {
+	return string(*ca)
+}
+
+// sdk returns the algorithm as used by the S3 API.
+func (ca ChecksumAlgorithm) sdk() types.ChecksumAlgorithm {
+	switch ca {
+	case ChecksumAlgorithmCRC32C:
+		return types.ChecksumAlgorithmCrc32c
+	case ChecksumAlgorithmCRC64NVME:
+		return types.ChecksumAlgorithmCrc64nvme
+	case ChecksumAlgorithmSHA256:
+		return types.ChecksumAlgorithmSha256
+	default:
+		return ""
+	}
+}
+
+// checksumType returns how part checksums are combined for multipart uploads. CRC based
+// checksums are combined to a checksum of the full object, SHA-256 only supports composite
+// checksums.
+func (ca ChecksumAlgorithm) checksumType() types.ChecksumType {
+	if ca == ChecksumAlgorithmSHA256 {
+		return types.ChecksumTypeComposite
+	}
+
+	return types.ChecksumTypeFullObject
+}
+
+func (ca ChecksumAlgorithm) newHash() hash.Hash {
+	switch ca {
+	case ChecksumAlgorithmCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case ChecksumAlgorithmCRC64NVME:
+		return crc64.New(crc64.MakeTable(crc64NVME))
+	default:
+		return sha256.New()
+	}
+}
+
+// fromHead returns the stored checksum of the object.
+func (ca ChecksumAlgorithm) fromHead(head *s3.HeadObjectOutput) string {
+	return ca.pick(head.ChecksumCRC32C, head.ChecksumCRC64NVME, head.ChecksumSHA256)
+}
+
+// fromPart returns the checksum of an uploaded part.
+func (ca ChecksumAlgorithm) fromPart(part *s3.UploadPartOutput) string {
+	return ca.pick(part.ChecksumCRC32C, part.ChecksumCRC64NVME, part.ChecksumSHA256)
+}
+
+// fromListedPart returns the checksum of a part listed in an unfinished upload.
+func (ca ChecksumAlgorithm) fromListedPart(part types.Part) string {
+	return ca.pick(part.ChecksumCRC32C, part.ChecksumCRC64NVME, part.ChecksumSHA256)
+}
+
+// completedPart returns the part with its checksum as required to complete the upload.
+func (ca ChecksumAlgorithm) completedPart(part MultipartPart) types.CompletedPart {
+	completed := types.CompletedPart{
+		PartNumber: aws.Int32(part.Number),
+		ETag:       aws.String(part.ETag),
+	}
+
+	if part.Checksum == "" {
+		return completed
+	}
+
+	switch ca {
+	case ChecksumAlgorithmCRC32C:
+		completed.ChecksumCRC32C = aws.String(part.Checksum)
+	case ChecksumAlgorithmCRC64NVME:
+		completed.ChecksumCRC64NVME = aws.String(part.Checksum)
+	case ChecksumAlgorithmSHA256:
+		completed.ChecksumSHA256 = aws.String(part.Checksum)
+	}
+
+	return completed
+}
+
+func (ca ChecksumAlgorithm) pick(crc32c, crc64nvme, sha *string) string {
+	switch ca {
+	case ChecksumAlgorithmCRC32C:
+		return aws.ToString(crc32c)
+	case ChecksumAlgorithmCRC64NVME:
+		return aws.ToString(crc64nvme)
+	case ChecksumAlgorithmSHA256:
+		return aws.ToString(sha)
+	default:
+		return ""
+	}
+}
+
+// FileChecksum returns the checksum of the local file that is compared with remote objects.
+func (u *S3) FileChecksum(path string, open OpenFunc) (string, error) {
+	file, err := openLocal(path, open)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return u.fileChecksum(file)
+}
+
+// fileChecksum calculates the checksum S3 stores for the file. Without a checksum algorithm
+// this is the ETag. Files uploaded in parts with a composite checksum get the checksum of
+// the concatenated part checksums followed by the number of parts.
+func (u *S3) fileChecksum(file LocalFile) (string, error) {
+	alg := u.ChecksumAlgorithm
+	if alg == "" {
+		return u.fileETag(file)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if !u.useMultipart(info.Size()) || alg.checksumType() == types.ChecksumTypeFullObject {
+		h := alg.newHash()
+		if _, err := io.Copy(h, io.NewSectionReader(file, 0, info.Size())); err != nil {
+			return "", err
+		}
+
+		return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+	}
+
+	sums := alg.newHash()
+	parts := 0
+
+	for offset := int64(0); offset < info.Size(); offset += u.PartSize {
+		h := alg.newHash()
+		if _, err := io.Copy(h, io.NewSectionReader(file, offset, u.PartSize)); err != nil {
+			return "", err
+		}
+
+		sums.Write(h.Sum(nil))
+		parts++
+	}
+
+	return fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(sums.Sum(nil)), parts), nil
+}
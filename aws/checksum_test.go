@@ -1,8 +1,11 @@
 package aws
 
 import (
+	"os"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -92,3 +95,148 @@ func TestChecksumMode_String(t *testing.T) {
 		})
 	}
 }
+
+func TestChecksumAlgorithm_Set(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    ChecksumAlgorithm
+		wantErr error
+	}{
+		{
+			name:  "set empty algorithm",
+			value: "",
+			want:  "",
+		},
+		{
+			name:  "set crc32c algorithm",
+			value: "crc32c",
+			want:  ChecksumAlgorithmCRC32C,
+		},
+		{
+			name:  "set crc64nvme algorithm",
+			value: "crc64nvme",
+			want:  ChecksumAlgorithmCRC64NVME,
+		},
+		{
+			name:  "set sha256 algorithm",
+			value: "sha256",
+			want:  ChecksumAlgorithmSHA256,
+		},
+		{
+			name:    "error on invalid algorithm",
+			value:   "md5",
+			wantErr: ErrInvalidChecksumAlgorithm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var alg ChecksumAlgorithm
+
+			err := alg.Set(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, alg)
+			assert.Equal(t, tt.value, alg.String())
+		})
+	}
+}
+
+func TestS3_FileChecksum(t *testing.T) {
+	t.Parallel()
+
+	name := createTempFile(t, "file.txt")
+
+	tests := []struct {
+		name      string
+		algorithm ChecksumAlgorithm
+		want      string
+	}{
+		{
+			name:      "etag without algorithm",
+			algorithm: "",
+			want:      "5d41402abc4b2a76b9719d911017c592",
+		},
+		{
+			name:      "crc32c checksum",
+			algorithm: ChecksumAlgorithmCRC32C,
+			want:      "mnG7TA==",
+		},
+		{
+			name:      "crc64nvme checksum",
+			algorithm: ChecksumAlgorithmCRC64NVME,
+			want:      "M3eFcAZSQlc=",
+		},
+		{
+			name:      "sha256 checksum",
+			algorithm: ChecksumAlgorithmSHA256,
+			want:      "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, _ := os.Open(name)
+			defer file.Close()
+
+			got, err := (&S3{ChecksumAlgorithm: tt.algorithm}).fileChecksum(file)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestS3_ContentChangedChecksumAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	name := createTempFile(t, "file.txt")
+
+	tests := []struct {
+		name string
+		head *s3.HeadObjectOutput
+		want bool
+	}{
+		{
+			name: "unchanged checksum",
+			head: &s3.HeadObjectOutput{ChecksumCRC32C: aws.String("mnG7TA==")},
+			want: false,
+		},
+		{
+			name: "changed checksum",
+			head: &s3.HeadObjectOutput{ChecksumCRC32C: aws.String("AAAAAA==")},
+			want: true,
+		},
+		{
+			name: "missing checksum ignores etag",
+			head: &s3.HeadObjectOutput{ETag: aws.String(`"5d41402abc4b2a76b9719d911017c592"`)},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, _ := os.Open(name)
+			defer file.Close()
+
+			got, err := (&S3{ChecksumAlgorithm: ChecksumAlgorithmCRC32C}).contentChanged(file, tt.head)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
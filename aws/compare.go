@@ -0,0 +1,94 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type CompareMode string
+
+const (
+	CompareChecksum     CompareMode = "checksum"
+	CompareSize         CompareMode = "size"
+	CompareSizeAndMtime CompareMode = "size-and-mtime"
+)
+
+// MetadataMtime is the metadata key of the local modification time in `size-and-mtime` mode.
+const MetadataMtime = "mtime"
+
+var ErrInvalidCompareMode = errors.New("invalid compare mode")
+
+func (cm *CompareMode) Set(value string) error {
+	switch CompareMode(value) {
+	case CompareChecksum, CompareSize, CompareSizeAndMtime:
+		*cm = CompareMode(value)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidCompareMode, value)
+	}
+}
+
+func (cm *CompareMode) String() string {
+	return string(*cm)
+}
+
+// formatMtime returns the modification time as stored in the object metadata.
+func formatMtime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// contentChanged reports whether the local file differs from the remote object according
+// to the compare mode. Unless the checksum mode is used, the file content is not read.
+func (u *S3) contentChanged(file LocalFile, head *s3.HeadObjectOutput) (bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	switch u.Compare {
+	case CompareSize:
+		return info.Size() != aws.ToInt64(head.ContentLength), nil
+	case CompareSizeAndMtime:
+		return info.Size() != aws.ToInt64(head.ContentLength) ||
+			head.Metadata[MetadataMtime] != formatMtime(info.ModTime()), nil
+	default:
+		sum, err := u.fileChecksum(file)
+		if err != nil {
+			return false, err
+		}
+
+		return sum != u.remoteChecksum(head), nil
+	}
+}
+
+// remoteChecksum returns the stored checksum of the object, which is the ETag unless a
+// checksum algorithm is used.
+func (u *S3) remoteChecksum(head *s3.HeadObjectOutput) string {
+	if u.ChecksumAlgorithm != "" {
+		return u.ChecksumAlgorithm.fromHead(head)
+	}
+
+	return strings.Trim(aws.ToString(head.ETag), `"'`)
+}
+
+// compareMetadata adds the metadata required by the compare mode.
+func (u *S3) compareMetadata(file LocalFile, metadata map[string]string) error {
+	if u.Compare != CompareSizeAndMtime {
+		return nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	metadata[MetadataMtime] = formatMtime(info.ModTime())
+
+	return nil
+}
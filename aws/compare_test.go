@@ -0,0 +1,140 @@
+package aws
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareMode_Set(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    CompareMode
+		wantErr error
+	}{
+		{
+			name:  "set checksum mode",
+			value: "checksum",
+			want:  CompareChecksum,
+		},
+		{
+			name:  "set size mode",
+			value: "size",
+			want:  CompareSize,
+		},
+		{
+			name:  "set size and mtime mode",
+			value: "size-and-mtime",
+			want:  CompareSizeAndMtime,
+		},
+		{
+			name:    "error on invalid mode",
+			value:   "invalid",
+			wantErr: ErrInvalidCompareMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mode CompareMode
+
+			err := mode.Set(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, mode)
+			assert.Equal(t, tt.value, mode.String())
+		})
+	}
+}
+
+func TestS3_ContentChanged(t *testing.T) {
+	t.Parallel()
+
+	name := createTempFile(t, "file.txt")
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	_ = os.Chtimes(name, mtime, mtime)
+
+	tests := []struct {
+		name    string
+		compare CompareMode
+		head    *s3.HeadObjectOutput
+		want    bool
+	}{
+		{
+			name:    "unchanged checksum",
+			compare: CompareChecksum,
+			head:    &s3.HeadObjectOutput{ETag: aws.String(`"5d41402abc4b2a76b9719d911017c592"`)},
+			want:    false,
+		},
+		{
+			name:    "changed checksum",
+			compare: CompareChecksum,
+			head:    &s3.HeadObjectOutput{ETag: aws.String(`"00000000000000000000000000000000"`)},
+			want:    true,
+		},
+		{
+			name:    "unchanged size ignores checksum",
+			compare: CompareSize,
+			head:    &s3.HeadObjectOutput{ContentLength: aws.Int64(5), ETag: aws.String(`"0"`)},
+			want:    false,
+		},
+		{
+			name:    "changed size",
+			compare: CompareSize,
+			head:    &s3.HeadObjectOutput{ContentLength: aws.Int64(6)},
+			want:    true,
+		},
+		{
+			name:    "unchanged size and mtime",
+			compare: CompareSizeAndMtime,
+			head: &s3.HeadObjectOutput{
+				ContentLength: aws.Int64(5),
+				Metadata:      map[string]string{MetadataMtime: "2024-01-02T03:04:05Z"},
+			},
+			want: false,
+		},
+		{
+			name:    "changed mtime",
+			compare: CompareSizeAndMtime,
+			head: &s3.HeadObjectOutput{
+				ContentLength: aws.Int64(5),
+				Metadata:      map[string]string{MetadataMtime: "2024-01-01T00:00:00Z"},
+			},
+			want: true,
+		},
+		{
+			name:    "missing mtime",
+			compare: CompareSizeAndMtime,
+			head:    &s3.HeadObjectOutput{ContentLength: aws.Int64(5)},
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, _ := os.Open(name)
+			defer file.Close()
+
+			got, err := (&S3{Compare: tt.compare}).contentChanged(file, tt.head)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
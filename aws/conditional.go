@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	// errCodePreconditionFailed is returned if the ETag of the object does not match the condition.
+	errCodePreconditionFailed = "PreconditionFailed"
+	// errCodeConditionalRequestConflict is returned if the object is written concurrently.
+	errCodeConditionalRequestConflict = "ConditionalRequestConflict"
+)
+
+var ErrConflict = errors.New("object was modified concurrently")
+
+// conditionFailed reports whether the precondition of a conditional request failed.
+func conditionFailed(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.ErrorCode() == errCodePreconditionFailed || apiErr.ErrorCode() == errCodeConditionalRequestConflict
+}
+
+// conflict wraps failed preconditions of conditional writes into ErrConflict.
+func conflict(key string, err error) error {
+	if !conditionFailed(err) {
+		return err
+	}
+
+	return fmt.Errorf("%w: '%s': %w", ErrConflict, key, err)
+}
+
+// conditionPut only writes the object if it is unchanged since the HEAD request. A nil head
+// means that the object did not exist.
+func (u *S3) conditionPut(input *s3.PutObjectInput, head *s3.HeadObjectOutput) {
+	if !u.ConditionalWrites {
+		return
+	}
+
+	if head == nil {
+		input.IfNoneMatch = aws.String("*")
+
+		return
+	}
+
+	input.IfMatch = head.ETag
+}
+
+// conditionCopy only updates the object if it is unchanged since the HEAD request.
+func (u *S3) conditionCopy(input *s3.CopyObjectInput, head *s3.HeadObjectOutput) {
+	if u.ConditionalWrites {
+		input.CopySourceIfMatch = head.ETag
+	}
+}
+
+// conditionCopyTarget only writes the copy if the target is unchanged since the HEAD request.
+// A nil head means that the target did not exist.
+func (u *S3) conditionCopyTarget(input *s3.CopyObjectInput, head *s3.HeadObjectOutput) {
+	if !u.ConditionalWrites {
+		return
+	}
+
+	if head == nil {
+		input.IfNoneMatch = aws.String("*")
+
+		return
+	}
+
+	input.IfMatch = head.ETag
+}
+
+// conditionHead reads the object before it is written with a condition. A nil head means that
+// the object does not exist, no request is sent without conditional writes.
+func (u *S3) conditionHead(ctx context.Context, key string) (*s3.HeadObjectOutput, error) {
+	if !u.ConditionalWrites {
+		return nil, nil //nolint:nilnil
+	}
+
+	head, err := u.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &u.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		var notFoundErr *types.NotFound
+		if errors.As(err, &notFoundErr) {
+			return nil, nil //nolint:nilnil
+		}
+
+		return nil, err
+	}
+
+	return head, nil
+}
@@ -0,0 +1,153 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+func TestS3_UploadConditionalWrites(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		setup   func(m *mocks.MockS3APIClient)
+		wantErr error
+	}{
+		{
+			name: "new key requires missing object",
+			setup: func(m *mocks.MockS3APIClient) {
+				m.On("HeadObject", mock.Anything, mock.Anything).Return(nil, &types.NotFound{})
+				m.On("PutObject", mock.Anything, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+					return aws.ToString(input.IfNoneMatch) == "*" && input.IfMatch == nil
+				})).Return(&s3.PutObjectOutput{}, nil)
+			},
+		},
+		{
+			name: "changed key requires compared etag",
+			setup: func(m *mocks.MockS3APIClient) {
+				m.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{ETag: aws.String(`"0"`)}, nil)
+				m.On("PutObject", mock.Anything, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+					return aws.ToString(input.IfMatch) == `"0"` && input.IfNoneMatch == nil
+				})).Return(&s3.PutObjectOutput{}, nil)
+			},
+		},
+		{
+			name: "metadata update requires compared etag",
+			setup: func(m *mocks.MockS3APIClient) {
+				m.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{
+					ETag:        aws.String(`"5d41402abc4b2a76b9719d911017c592"`),
+					ContentType: aws.String("text/html"),
+				}, nil)
+				m.On("CopyObject", mock.Anything, mock.MatchedBy(func(input *s3.CopyObjectInput) bool {
+					return aws.ToString(input.CopySourceIfMatch) == `"5d41402abc4b2a76b9719d911017c592"`
+				})).Return(&s3.CopyObjectOutput{}, nil)
+			},
+		},
+		{
+			name: "error on concurrent modification",
+			setup: func(m *mocks.MockS3APIClient) {
+				m.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{ETag: aws.String(`"0"`)}, nil)
+				m.On("PutObject", mock.Anything, mock.Anything).Return(
+					nil, &smithy.GenericAPIError{Code: errCodePreconditionFailed},
+				)
+			},
+			wantErr: ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			tt.setup(mockS3Client)
+
+			u := &S3{client: mockS3Client, Bucket: "test-bucket", ConditionalWrites: true}
+
+			err := u.Upload(t.Context(), S3UploadOptions{
+				LocalFilePath:   createTempFile(t, "file.txt"),
+				RemoteObjectKey: "file.txt",
+				ContentType:     map[string]string{".txt": "text/plain"},
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestS3_WriteConditionalWrites(t *testing.T) {
+	t.Parallel()
+
+	failed := &smithy.GenericAPIError{Code: "PreconditionFailed"}
+
+	t.Run("redirect to new object", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{}, &types.NotFound{})
+		mockS3Client.On("PutObject", mock.Anything, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+			return aws.ToString(input.IfNoneMatch) == "*"
+		})).Return(&s3.PutObjectOutput{}, nil)
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", ConditionalWrites: true}
+
+		assert.NoError(t, u.Redirect(t.Context(), S3RedirectOptions{Path: "old", Location: "/new"}))
+	})
+
+	t.Run("conflict on put of modified object", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{ETag: aws.String(`"1"`)}, nil)
+		mockS3Client.On("PutObject", mock.Anything, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+			return aws.ToString(input.IfMatch) == `"1"`
+		})).Return(&s3.PutObjectOutput{}, failed)
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", ConditionalWrites: true}
+
+		err := u.Put(t.Context(), S3PutOptions{RemoteObjectKey: "sums.txt", Body: []byte("sums")})
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("conflict on copy to modified object", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{ETag: aws.String(`"1"`)}, nil)
+		mockS3Client.On("CopyObject", mock.Anything, mock.MatchedBy(func(input *s3.CopyObjectInput) bool {
+			return aws.ToString(input.IfMatch) == `"1"`
+		})).Return(&s3.CopyObjectOutput{}, failed)
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", ConditionalWrites: true}
+
+		err := u.Copy(t.Context(), S3CopyOptions{SourceObjectKey: "v1/index.html", RemoteObjectKey: "latest/index.html"})
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("conflict on delete of modified object", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{ETag: aws.String(`"1"`)}, nil)
+		mockS3Client.On("DeleteObject", mock.Anything, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+			return aws.ToString(input.IfMatch) == `"1"`
+		})).Return(&s3.DeleteObjectOutput{}, failed)
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", ConditionalWrites: true}
+
+		err := u.Delete(t.Context(), S3DeleteOptions{RemoteObjectKey: "old.html"})
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+}
@@ -0,0 +1,208 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// metadataHeaderPrefix is the canonical prefix of headers stored as object metadata.
+const metadataHeaderPrefix = "X-Amz-Meta-"
+
+var (
+	ErrInvalidExpires    = errors.New("invalid expires value")
+	ErrUnsupportedHeader = errors.New("header cannot be stored by S3")
+)
+
+// objectHeaders are the optional HTTP headers of an object that are only sent if configured.
+type objectHeaders struct {
+	ContentDisposition      string
+	ContentLanguage         string
+	Expires                 *time.Time
+	WebsiteRedirectLocation string
+}
+
+// fileHeaders are the effective headers of an uploaded file.
+type fileHeaders struct {
+	objectHeaders
+	ACL             string
+	ContentType     string
+	ContentEncoding string
+	CacheControl    string
+	Metadata        map[string]string
+}
+
+// getFileHeaders returns all headers for the given file based on the provided patterns.
+// Headers configured for the single file take precedence over the patterns.
+func (u *S3) getFileHeaders(opt S3UploadOptions) (fileHeaders, error) {
+	optional, err := getHeaders(opt)
+	if err != nil {
+		return fileHeaders{}, err
+	}
+
+	headers := fileHeaders{
+		objectHeaders:   optional,
+		ACL:             getACL(opt.LocalFilePath, opt.ACL),
+		ContentType:     u.getContentType(opt.LocalFilePath, opt.Open, opt.ContentType),
+		ContentEncoding: getContentEncoding(opt.LocalFilePath, opt.ContentEncoding),
+		CacheControl:    getCacheControl(opt.LocalFilePath, opt.CacheControl),
+		Metadata:        getMetadata(opt.LocalFilePath, opt.Metadata),
+	}
+
+	for name, value := range opt.Headers {
+		if err := headers.set(name, value); err != nil {
+			return fileHeaders{}, err
+		}
+	}
+
+	return headers, nil
+}
+
+// ValidateHeader returns an error if the HTTP header cannot be represented by an object property.
+func ValidateHeader(name, value string) error {
+	headers := fileHeaders{Metadata: make(map[string]string)}
+
+	return headers.set(name, value)
+}
+
+// set maps the HTTP header onto the corresponding object property.
+func (h *fileHeaders) set(name, value string) error {
+	name = http.CanonicalHeaderKey(name)
+
+	switch name {
+	case "X-Amz-Acl":
+		h.ACL = value
+	case "Cache-Control":
+		h.CacheControl = value
+	case "Content-Type":
+		h.ContentType = value
+	case "Content-Encoding":
+		h.ContentEncoding = value
+	case "Content-Disposition":
+		h.ContentDisposition = value
+	case "Content-Language":
+		h.ContentLanguage = value
+	case "X-Amz-Website-Redirect-Location":
+		h.WebsiteRedirectLocation = value
+	case "Expires":
+		expires, err := ParseExpires(value)
+		if err != nil {
+			return err
+		}
+
+		h.Expires = &expires
+	default:
+		key, ok := strings.CutPrefix(name, metadataHeaderPrefix)
+		if !ok || key == "" {
+			return fmt.Errorf("%w: %s", ErrUnsupportedHeader, name)
+		}
+
+		h.Metadata[strings.ToLower(key)] = value
+	}
+
+	return nil
+}
+
+// getHeaders returns the optional headers for the given file based on the provided patterns.
+func getHeaders(opt S3UploadOptions) (objectHeaders, error) {
+	headers := objectHeaders{
+		ContentDisposition: matchPattern(opt.LocalFilePath, opt.ContentDisposition),
+		ContentLanguage:    matchPattern(opt.LocalFilePath, opt.ContentLanguage),
+
+		WebsiteRedirectLocation: matchPattern(opt.LocalFilePath, opt.WebsiteRedirect),
+	}
+
+	if value := matchPattern(opt.LocalFilePath, opt.Expires); value != "" {
+		expires, err := ParseExpires(value)
+		if err != nil {
+			return objectHeaders{}, err
+		}
+
+		headers.Expires = &expires
+	}
+
+	return headers, nil
+}
+
+// ParseExpires parses an absolute expiry date in RFC 3339 or HTTP date format.
+func ParseExpires(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, http.TimeFormat} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidExpires, value)
+}
+
+// matchPattern returns the value of the first pattern that matches the file.
+func matchPattern(file string, patterns map[string]string) string {
+	for pattern, value := range patterns {
+		if match, _ := filepath.Match(pattern, file); match {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// changed reports whether the headers differ from the remote object and describes the change.
+func (h objectHeaders) changed(head *s3.HeadObjectOutput) (bool, string) {
+	if previous := aws.ToString(head.ContentDisposition); previous != h.ContentDisposition {
+		return true, fmt.Sprintf("content-disposition has changed from '%s' to '%s'", previous, h.ContentDisposition)
+	}
+
+	if previous := aws.ToString(head.ContentLanguage); previous != h.ContentLanguage {
+		return true, fmt.Sprintf("content-language has changed from '%s' to '%s'", previous, h.ContentLanguage)
+	}
+
+	if previous, expires := formatExpires(head.Expires), formatExpires(h.Expires); previous != expires {
+		return true, fmt.Sprintf("expires has changed from '%s' to '%s'", previous, expires)
+	}
+
+	if previous := aws.ToString(head.WebsiteRedirectLocation); previous != h.WebsiteRedirectLocation {
+		return true, fmt.Sprintf(
+			"website-redirect-location has changed from '%s' to '%s'", previous, h.WebsiteRedirectLocation,
+		)
+	}
+
+	return false, ""
+}
+
+// applyPut sets the configured headers on the upload input.
+func (h objectHeaders) applyPut(input *s3.PutObjectInput) {
+	input.ContentDisposition = optionalString(h.ContentDisposition)
+	input.ContentLanguage = optionalString(h.ContentLanguage)
+	input.Expires = h.Expires
+	input.WebsiteRedirectLocation = optionalString(h.WebsiteRedirectLocation)
+}
+
+// applyCopy sets the configured headers on the copy input.
+func (h objectHeaders) applyCopy(input *s3.CopyObjectInput) {
+	input.ContentDisposition = optionalString(h.ContentDisposition)
+	input.ContentLanguage = optionalString(h.ContentLanguage)
+	input.Expires = h.Expires
+	input.WebsiteRedirectLocation = optionalString(h.WebsiteRedirectLocation)
+}
+
+func formatExpires(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return aws.String(value)
+}
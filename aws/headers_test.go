@@ -0,0 +1,173 @@
+package aws
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+func TestParseExpires(t *testing.T) {
+	t.Parallel()
+
+	want := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr error
+	}{
+		{
+			name:  "rfc 3339",
+			value: "2030-01-02T04:04:05+01:00",
+			want:  want,
+		},
+		{
+			name:  "http date",
+			value: "Wed, 02 Jan 2030 03:04:05 GMT",
+			want:  want,
+		},
+		{
+			name:    "error on relative value",
+			value:   "24h",
+			wantErr: ErrInvalidExpires,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseExpires(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestObjectHeaders_Changed(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		headers objectHeaders
+		head    *s3.HeadObjectOutput
+		want    bool
+	}{
+		{
+			name:    "unset headers",
+			headers: objectHeaders{},
+			head:    &s3.HeadObjectOutput{},
+			want:    false,
+		},
+		{
+			name:    "unchanged headers",
+			headers: objectHeaders{ContentDisposition: "attachment", ContentLanguage: "de", Expires: &expires},
+			head: &s3.HeadObjectOutput{
+				ContentDisposition: aws.String("attachment"),
+				ContentLanguage:    aws.String("de"),
+				Expires:            aws.Time(expires.In(time.FixedZone("CET", 3600))),
+			},
+			want: false,
+		},
+		{
+			name:    "added content-disposition",
+			headers: objectHeaders{ContentDisposition: "attachment"},
+			head:    &s3.HeadObjectOutput{},
+			want:    true,
+		},
+		{
+			name:    "removed content-language",
+			headers: objectHeaders{},
+			head:    &s3.HeadObjectOutput{ContentLanguage: aws.String("de")},
+			want:    true,
+		},
+		{
+			name:    "changed expires",
+			headers: objectHeaders{Expires: &expires},
+			head:    &s3.HeadObjectOutput{Expires: aws.Time(expires.Add(time.Hour))},
+			want:    true,
+		},
+		{
+			name:    "changed website redirect location",
+			headers: objectHeaders{WebsiteRedirectLocation: "/new/"},
+			head:    &s3.HeadObjectOutput{WebsiteRedirectLocation: aws.String("/old/")},
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, _ := tt.headers.changed(tt.head)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestS3_UploadContentDisposition(t *testing.T) {
+	t.Parallel()
+
+	file := createTempFile(t, "file.zip")
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{
+		ETag:        aws.String(`"5d41402abc4b2a76b9719d911017c592"`),
+		ContentType: aws.String("application/zip"),
+	}, nil)
+	mockS3Client.On("CopyObject", mock.Anything, mock.MatchedBy(func(input *s3.CopyObjectInput) bool {
+		return aws.ToString(input.ContentDisposition) == "attachment"
+	})).Return(&s3.CopyObjectOutput{}, nil)
+
+	u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+
+	err := u.Upload(t.Context(), S3UploadOptions{
+		LocalFilePath:      file,
+		RemoteObjectKey:    "file.zip",
+		ContentType:        map[string]string{".zip": "application/zip"},
+		ContentDisposition: map[string]string{filepath.Join(filepath.Dir(file), "*.zip"): "attachment"},
+	})
+
+	assert.NoError(t, err)
+	mockS3Client.AssertExpectations(t)
+}
+
+func TestGetFileHeaders(t *testing.T) {
+	t.Parallel()
+
+	got, err := (&S3{}).getFileHeaders(S3UploadOptions{
+		LocalFilePath:   "/src/index.html",
+		ContentType:     map[string]string{".html": "text/html"},
+		CacheControl:    map[string]string{"/src/*": "max-age=60"},
+		WebsiteRedirect: map[string]string{"/src/index.html": "/new/"},
+		Headers: map[string]string{
+			"Cache-Control":    "no-cache",
+			"X-Amz-Meta-Owner": "frontend",
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "text/html", got.ContentType)
+	assert.Equal(t, "no-cache", got.CacheControl)
+	assert.Equal(t, map[string]string{"owner": "frontend"}, got.Metadata)
+	assert.Equal(t, "/new/", got.WebsiteRedirectLocation)
+
+	_, err = (&S3{}).getFileHeaders(S3UploadOptions{Headers: map[string]string{"Strict-Transport-Security": "max-age=60"}})
+	assert.ErrorIs(t, err, ErrUnsupportedHeader)
+}
@@ -0,0 +1,31 @@
+package aws
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// LocalFile is the content of a file that is uploaded. Besides files on disk this can be
+// an entry of an archive that is held in memory.
+type LocalFile interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+	Stat() (fs.FileInfo, error)
+}
+
+// OpenFunc opens the content of a local file.
+type OpenFunc func() (LocalFile, error)
+
+// openLocal opens the local file with the given function or from disk if it is nil.
+//
+//nolint:ireturn
+func openLocal(path string, open OpenFunc) (LocalFile, error) {
+	if open != nil {
+		return open()
+	}
+
+	return os.Open(path)
+}
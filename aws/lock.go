@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrLockHeld = errors.New("lock is held")
+	ErrLockLost = errors.New("lock was taken over")
+)
+
+// DeployLock is the content of the lock object.
+type DeployLock struct {
+	Owner    string    `json:"owner"`
+	Pipeline string    `json:"pipeline,omitempty"`
+	Expires  time.Time `json:"expires"`
+}
+
+type S3LockOptions struct {
+	RemoteObjectKey string
+	Owner           string
+	Pipeline        string
+	// TTL is the time after which the lock is considered abandoned and may be taken over.
+	TTL time.Duration
+}
+
+// Lock is an acquired lock object.
+type Lock struct {
+	opt S3LockOptions
+
+	mu   sync.Mutex
+	etag string
+}
+
+// AcquireLock creates the lock object if it does not exist. An expired lock is removed and
+// acquired again, a lock that is held by another run returns ErrLockHeld.
+func (u *S3) AcquireLock(ctx context.Context, opt S3LockOptions) (*Lock, error) {
+	data, err := opt.content()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		resp, err := u.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(u.Bucket),
+			Key:         aws.String(opt.RemoteObjectKey),
+			Body:        bytes.NewReader(data),
+			ContentType: aws.String("application/json"),
+			IfNoneMatch: aws.String("*"),
+		})
+		if err == nil {
+			return &Lock{opt: opt, etag: aws.ToString(resp.ETag)}, nil
+		}
+
+		if !conditionFailed(err) {
+			return nil, err
+		}
+
+		held, etag, err := u.readLock(ctx, opt.RemoteObjectKey)
+		if err != nil {
+			return nil, err
+		}
+
+		// The lock was released in the meantime.
+		if held == nil {
+			continue
+		}
+
+		if held.Expires.After(time.Now()) {
+			return nil, fmt.Errorf(
+				"%w by '%s' (pipeline %s) until %s",
+				ErrLockHeld, held.Owner, held.Pipeline, held.Expires.Format(time.RFC3339),
+			)
+		}
+
+		log.Warn().Msgf("taking over expired lock '%s' of '%s'", opt.RemoteObjectKey, held.Owner)
+
+		// Only the expired lock is removed, a concurrent takeover fails the precondition.
+		if err := u.deleteLock(ctx, opt.RemoteObjectKey, etag); err != nil && !conditionFailed(err) {
+			return nil, err
+		}
+	}
+}
+
+// RefreshLock extends the expiry of the lock by the TTL. A lock that was taken over by another
+// run returns ErrLockLost.
+func (u *S3) RefreshLock(ctx context.Context, lock *Lock) error {
+	data, err := lock.opt.content()
+	if err != nil {
+		return err
+	}
+
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
+	resp, err := u.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(u.Bucket),
+		Key:         aws.String(lock.opt.RemoteObjectKey),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+		IfMatch:     aws.String(lock.etag),
+	})
+	if conditionFailed(err) {
+		return fmt.Errorf("%w: %s", ErrLockLost, lock.opt.RemoteObjectKey)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	lock.etag = aws.ToString(resp.ETag)
+
+	return nil
+}
+
+// ReleaseLock removes the lock object unless it was taken over by another run.
+func (u *S3) ReleaseLock(ctx context.Context, lock *Lock) error {
+	if lock == nil {
+		return nil
+	}
+
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
+	err := u.deleteLock(ctx, lock.opt.RemoteObjectKey, lock.etag)
+	if conditionFailed(err) {
+		log.Warn().Msgf("lock '%s' was taken over by another run", lock.opt.RemoteObjectKey)
+
+		return nil
+	}
+
+	return err
+}
+
+// content returns the lock object that expires after the TTL from now.
+func (opt S3LockOptions) content() ([]byte, error) {
+	return json.Marshal(DeployLock{
+		Owner:    opt.Owner,
+		Pipeline: opt.Pipeline,
+		Expires:  time.Now().Add(opt.TTL).UTC(),
+	})
+}
+
+// readLock returns the current lock and its ETag. A missing lock returns nil.
+func (u *S3) readLock(ctx context.Context, key string) (*DeployLock, string, error) {
+	resp, err := u.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(u.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKeyErr *types.NoSuchKey
+		if errors.As(err, &noSuchKeyErr) {
+			return nil, "", nil
+		}
+
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	lock := &DeployLock{}
+	if err := json.NewDecoder(resp.Body).Decode(lock); err != nil {
+		return nil, "", fmt.Errorf("failed to parse lock '%s': %w", key, err)
+	}
+
+	return lock, aws.ToString(resp.ETag), nil
+}
+
+func (u *S3) deleteLock(ctx context.Context, key, etag string) error {
+	_, err := u.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:  aws.String(u.Bucket),
+		Key:     aws.String(key),
+		IfMatch: aws.String(etag),
+	})
+
+	return err
+}
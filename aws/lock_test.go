@@ -0,0 +1,153 @@
+package aws
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+func TestS3_AcquireLock(t *testing.T) {
+	t.Parallel()
+
+	lockBody := func(expires time.Time) io.ReadCloser {
+		return io.NopCloser(strings.NewReader(
+			`{"owner":"other","pipeline":"7","expires":"` + expires.Format(time.RFC3339) + `"}`,
+		))
+	}
+	conflict := &smithy.GenericAPIError{Code: errCodePreconditionFailed}
+
+	tests := []struct {
+		name    string
+		setup   func(m *mocks.MockS3APIClient)
+		wantErr error
+	}{
+		{
+			name: "acquire free lock",
+			setup: func(m *mocks.MockS3APIClient) {
+				m.On("PutObject", mock.Anything, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+					return aws.ToString(input.IfNoneMatch) == "*"
+				})).Return(&s3.PutObjectOutput{ETag: aws.String(`"1"`)}, nil)
+			},
+		},
+		{
+			name: "error on held lock",
+			setup: func(m *mocks.MockS3APIClient) {
+				m.On("PutObject", mock.Anything, mock.Anything).Return(nil, conflict)
+				m.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{
+					Body: lockBody(time.Now().Add(time.Hour)),
+					ETag: aws.String(`"0"`),
+				}, nil)
+			},
+			wantErr: ErrLockHeld,
+		},
+		{
+			name: "take over expired lock",
+			setup: func(m *mocks.MockS3APIClient) {
+				m.On("PutObject", mock.Anything, mock.Anything).Return(nil, conflict).Once()
+				m.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{
+					Body: lockBody(time.Now().Add(-time.Hour)),
+					ETag: aws.String(`"0"`),
+				}, nil)
+				m.On("DeleteObject", mock.Anything, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+					return aws.ToString(input.IfMatch) == `"0"`
+				})).Return(&s3.DeleteObjectOutput{}, nil)
+				m.On("PutObject", mock.Anything, mock.Anything).Return(&s3.PutObjectOutput{ETag: aws.String(`"1"`)}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			tt.setup(mockS3Client)
+
+			u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+
+			lock, err := u.AcquireLock(t.Context(), S3LockOptions{
+				RemoteObjectKey: "site/.s3-action-lock.json",
+				Owner:           "repo",
+				TTL:             time.Hour,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "site/.s3-action-lock.json", lock.opt.RemoteObjectKey)
+			assert.Equal(t, `"1"`, lock.etag)
+		})
+	}
+}
+
+func TestS3_ReleaseLock(t *testing.T) {
+	t.Parallel()
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("DeleteObject", mock.Anything, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+		return aws.ToString(input.IfMatch) == `"1"`
+	})).Return(nil, &smithy.GenericAPIError{Code: errCodePreconditionFailed})
+
+	u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+
+	// A lock taken over by another run is left in place.
+	lock := &Lock{opt: S3LockOptions{RemoteObjectKey: "site/.s3-action-lock.json"}, etag: `"1"`}
+	assert.NoError(t, u.ReleaseLock(t.Context(), lock))
+	assert.NoError(t, u.ReleaseLock(t.Context(), nil))
+}
+
+func TestS3_RefreshLock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		err      error
+		wantETag string
+		wantErr  error
+	}{
+		{
+			name:     "extend lock",
+			wantETag: `"2"`,
+		},
+		{
+			name:     "error on lost lock",
+			err:      &smithy.GenericAPIError{Code: errCodePreconditionFailed},
+			wantETag: `"1"`,
+			wantErr:  ErrLockLost,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			mockS3Client.On("PutObject", mock.Anything, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+				return aws.ToString(input.IfMatch) == `"1"` && input.IfNoneMatch == nil
+			})).Return(&s3.PutObjectOutput{ETag: aws.String(`"2"`)}, tt.err)
+
+			u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+			lock := &Lock{opt: S3LockOptions{RemoteObjectKey: "site/.s3-action-lock.json", TTL: time.Hour}, etag: `"1"`}
+
+			err := u.RefreshLock(t.Context(), lock)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantETag, lock.etag)
+		})
+	}
+}
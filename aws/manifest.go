@@ -0,0 +1,211 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/rs/zerolog/log"
+)
+
+// ManifestVersion is the format version of the deploy manifest.
+const ManifestVersion = 1
+
+var ErrUnsupportedManifest = errors.New("unsupported manifest version")
+
+// Manifest records the state of all objects written by the last successful sync.
+type Manifest struct {
+	mu      sync.Mutex
+	Version int                       `json:"version"`
+	Objects map[string]ManifestObject `json:"objects"`
+}
+
+// ManifestObject is the content hash, size and effective headers of an object.
+type ManifestObject struct {
+	Hash             string            `json:"hash,omitempty"`
+	Size             int64             `json:"size,omitempty"`
+	ACL              string            `json:"acl,omitempty"`
+	ContentType      string            `json:"contentType,omitempty"`
+	ContentEncoding  string            `json:"contentEncoding,omitempty"`
+	CacheControl     string            `json:"cacheControl,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	RedirectLocation string            `json:"redirectLocation,omitempty"`
+
+	ContentDisposition string `json:"contentDisposition,omitempty"`
+	ContentLanguage    string `json:"contentLanguage,omitempty"`
+	Expires            string `json:"expires,omitempty"`
+}
+
+type S3ManifestOptions struct {
+	RemoteObjectKey string
+}
+
+// NewManifest creates an empty manifest.
+func NewManifest() *Manifest {
+	return &Manifest{
+		Version: ManifestVersion,
+		Objects: make(map[string]ManifestObject),
+	}
+}
+
+// Get returns the recorded state of the object. A nil manifest contains no objects.
+func (m *Manifest) Get(key string) (ManifestObject, bool) {
+	if m == nil {
+		return ManifestObject{}, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, ok := m.Objects[key]
+
+	return obj, ok
+}
+
+// Set records the state of the object. Objects are not recorded in a nil manifest.
+func (m *Manifest) Set(key string, obj ManifestObject) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Objects[key] = obj
+}
+
+// Equal reports whether both objects have the same content and headers.
+func (o ManifestObject) Equal(other ManifestObject) bool {
+	return o.Hash == other.Hash &&
+		o.Size == other.Size &&
+		o.ACL == other.ACL &&
+		o.ContentType == other.ContentType &&
+		o.ContentEncoding == other.ContentEncoding &&
+		o.CacheControl == other.CacheControl &&
+		o.RedirectLocation == other.RedirectLocation &&
+		o.ContentDisposition == other.ContentDisposition &&
+		o.ContentLanguage == other.ContentLanguage &&
+		o.Expires == other.Expires &&
+		maps.Equal(o.Metadata, other.Metadata)
+}
+
+// Describe returns the state the object will have after uploading the local file
+// without sending any request.
+func (u *S3) Describe(opt S3UploadOptions) (ManifestObject, error) {
+	file, err := openLocal(opt.LocalFilePath, opt.Open)
+	if err != nil {
+		return ManifestObject{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return ManifestObject{}, err
+	}
+
+	headers, err := u.getFileHeaders(opt)
+	if err != nil {
+		return ManifestObject{}, err
+	}
+
+	obj := ManifestObject{
+		Size:            info.Size(),
+		ACL:             headers.ACL,
+		ContentType:     headers.ContentType,
+		ContentEncoding: headers.ContentEncoding,
+		CacheControl:    headers.CacheControl,
+		Metadata:        headers.Metadata,
+
+		RedirectLocation: headers.WebsiteRedirectLocation,
+
+		ContentDisposition: headers.ContentDisposition,
+		ContentLanguage:    headers.ContentLanguage,
+		Expires:            formatExpires(headers.Expires),
+	}
+
+	if !u.aclEnabled() {
+		obj.ACL = ""
+	}
+
+	if err := u.compareMetadata(file, obj.Metadata); err != nil {
+		return ManifestObject{}, err
+	}
+
+	// The content is only hashed if changes are detected by checksum.
+	if u.Compare == "" || u.Compare == CompareChecksum {
+		if obj.Hash, err = u.fileChecksum(file); err != nil {
+			return ManifestObject{}, err
+		}
+	}
+
+	return obj, nil
+}
+
+// GetManifest downloads the manifest of the last successful sync. A missing manifest returns nil.
+func (u *S3) GetManifest(ctx context.Context, opt S3ManifestOptions) (*Manifest, error) {
+	resp, err := u.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(u.Bucket),
+		Key:    aws.String(opt.RemoteObjectKey),
+	})
+	if err != nil {
+		var noSuchKeyErr *types.NoSuchKey
+		if errors.As(err, &noSuchKeyErr) {
+			return nil, nil //nolint:nilnil
+		}
+
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	manifest := NewManifest()
+	if err := json.NewDecoder(resp.Body).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedManifest, manifest.Version)
+	}
+
+	return manifest, nil
+}
+
+// PutManifest uploads the manifest of a successful sync.
+func (u *S3) PutManifest(ctx context.Context, opt S3ManifestOptions, manifest *Manifest) error {
+	log.Debug().Msgf("writing manifest '%s' with %d objects", opt.RemoteObjectKey, len(manifest.Objects))
+
+	if u.DryRun {
+		return nil
+	}
+
+	manifest.mu.Lock()
+	data, err := json.Marshal(manifest)
+	manifest.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	head, err := u.conditionHead(ctx, opt.RemoteObjectKey)
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(u.Bucket),
+		Key:         aws.String(opt.RemoteObjectKey),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	}
+	u.conditionPut(input, head)
+
+	_, err = u.client.PutObject(ctx, input)
+
+	return conflict(opt.RemoteObjectKey, err)
+}
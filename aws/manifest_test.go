@@ -0,0 +1,165 @@
+package aws
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+var ErrGetObject = errors.New("get object failed")
+
+func TestS3_GetManifest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T) *S3
+		want    *Manifest
+		wantErr error
+	}{
+		{
+			name: "return nil when manifest does not exist",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{}, &types.NoSuchKey{})
+
+				return &S3{client: mockS3Client, Bucket: "test-bucket"}
+			},
+			want: nil,
+		},
+		{
+			name: "parse existing manifest",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{
+					Body: io.NopCloser(strings.NewReader(`{"version":1,"objects":{"file.txt":{"hash":"abc","size":5}}}`)),
+				}, nil)
+
+				return &S3{client: mockS3Client, Bucket: "test-bucket"}
+			},
+			want: &Manifest{
+				Version: ManifestVersion,
+				Objects: map[string]ManifestObject{"file.txt": {Hash: "abc", Size: 5}},
+			},
+		},
+		{
+			name: "error on unsupported version",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{
+					Body: io.NopCloser(strings.NewReader(`{"version":2,"objects":{}}`)),
+				}, nil)
+
+				return &S3{client: mockS3Client, Bucket: "test-bucket"}
+			},
+			wantErr: ErrUnsupportedManifest,
+		},
+		{
+			name: "error when get object fails",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{}, ErrGetObject)
+
+				return &S3{client: mockS3Client, Bucket: "test-bucket"}
+			},
+			wantErr: ErrGetObject,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.setup(t).GetManifest(t.Context(), S3ManifestOptions{RemoteObjectKey: ".s3-action-manifest.json"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestS3_PutManifest(t *testing.T) {
+	t.Parallel()
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("PutObject", mock.Anything, mock.MatchedBy(func(in *s3.PutObjectInput) bool {
+		data, _ := io.ReadAll(in.Body)
+
+		return *in.Key == "target/.s3-action-manifest.json" &&
+			string(data) == `{"version":1,"objects":{"file.txt":{"hash":"abc","size":5}}}`
+	})).Return(&s3.PutObjectOutput{}, nil)
+
+	manifest := NewManifest()
+	manifest.Set("file.txt", ManifestObject{Hash: "abc", Size: 5})
+
+	u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+	err := u.PutManifest(t.Context(), S3ManifestOptions{RemoteObjectKey: "target/.s3-action-manifest.json"}, manifest)
+
+	assert.NoError(t, err)
+}
+
+func TestS3_Describe(t *testing.T) {
+	t.Parallel()
+
+	file := createTempFile(t, "file.txt")
+	pattern := filepath.Join(filepath.Dir(file), "*.txt")
+
+	got, err := (&S3{}).Describe(S3UploadOptions{
+		LocalFilePath: file,
+		ACL:           map[string]string{pattern: "public-read"},
+		ContentType:   map[string]string{".txt": "text/plain"},
+		CacheControl:  map[string]string{pattern: "max-age=60"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ManifestObject{
+		Hash:         "5d41402abc4b2a76b9719d911017c592",
+		Size:         5,
+		ACL:          "public-read",
+		ContentType:  "text/plain",
+		CacheControl: "max-age=60",
+		Metadata:     map[string]string{},
+	}, got)
+}
+
+func TestManifestObject_Equal(t *testing.T) {
+	t.Parallel()
+
+	obj := ManifestObject{Hash: "abc", Size: 5, Metadata: map[string]string{"k": "v"}}
+
+	assert.True(t, obj.Equal(ManifestObject{Hash: "abc", Size: 5, Metadata: map[string]string{"k": "v"}}))
+	assert.False(t, obj.Equal(ManifestObject{Hash: "abc", Size: 5, Metadata: map[string]string{"k": "x"}}))
+	assert.False(t, obj.Equal(ManifestObject{Hash: "abd", Size: 5, Metadata: map[string]string{"k": "v"}}))
+}
+
+func TestManifest_Nil(t *testing.T) {
+	t.Parallel()
+
+	var manifest *Manifest
+
+	manifest.Set("file.txt", ManifestObject{})
+
+	_, ok := manifest.Get("file.txt")
+	assert.False(t, ok)
+}
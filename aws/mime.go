@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// sniffLength is the number of bytes considered by http.DetectContentType.
+const sniffLength = 512
+
+// builtinMIMETypes are the content types of common web assets. Types that are also known to
+// the Go standard library use the same values to keep existing objects unchanged.
+var builtinMIMETypes = map[string]string{
+	".7z":          "application/x-7z-compressed",
+	".apng":        "image/apng",
+	".asc":         "application/pgp-signature",
+	".atom":        "application/atom+xml",
+	".avif":        "image/avif",
+	".bmp":         "image/bmp",
+	".br":          "application/x-brotli",
+	".bz2":         "application/x-bzip2",
+	".csv":         "text/csv; charset=utf-8",
+	".css":         "text/css; charset=utf-8",
+	".deb":         "application/vnd.debian.binary-package",
+	".eot":         "application/vnd.ms-fontobject",
+	".flac":        "audio/flac",
+	".gif":         "image/gif",
+	".gz":          "application/gzip",
+	".heic":        "image/heic",
+	".htm":         "text/html; charset=utf-8",
+	".html":        "text/html; charset=utf-8",
+	".ico":         "image/vnd.microsoft.icon",
+	".ics":         "text/calendar; charset=utf-8",
+	".jpeg":        "image/jpeg",
+	".jpg":         "image/jpeg",
+	".js":          "text/javascript; charset=utf-8",
+	".json":        "application/json",
+	".jsonld":      "application/ld+json",
+	".jxl":         "image/jxl",
+	".m4a":         "audio/mp4",
+	".map":         "application/json",
+	".md":          "text/markdown; charset=utf-8",
+	".mjs":         "text/javascript; charset=utf-8",
+	".mov":         "video/quicktime",
+	".mp3":         "audio/mpeg",
+	".mp4":         "video/mp4",
+	".oga":         "audio/ogg",
+	".ogg":         "audio/ogg",
+	".ogv":         "video/ogg",
+	".otf":         "font/otf",
+	".pdf":         "application/pdf",
+	".png":         "image/png",
+	".rss":         "application/rss+xml",
+	".sig":         "application/pgp-signature",
+	".svg":         "image/svg+xml",
+	".tar":         "application/x-tar",
+	".tgz":         "application/gzip",
+	".tif":         "image/tiff",
+	".tiff":        "image/tiff",
+	".toml":        "application/toml",
+	".ttf":         "font/ttf",
+	".txt":         "text/plain; charset=utf-8",
+	".wasm":        "application/wasm",
+	".wav":         "audio/wav",
+	".webm":        "video/webm",
+	".webmanifest": "application/manifest+json",
+	".webp":        "image/webp",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".xml":         "text/xml; charset=utf-8",
+	".xz":          "application/x-xz",
+	".yaml":        "application/yaml",
+	".yml":         "application/yaml",
+	".zip":         "application/zip",
+	".zst":         "application/zstd",
+}
+
+// LoadMIMETypes reads a mapping of content types to file extensions in the format of
+// `/etc/mime.types`, e.g. `application/wasm wasm`.
+func LoadMIMETypes(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MIME types: %w", err)
+	}
+	defer file.Close()
+
+	types := make(map[string]string)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 { //nolint:mnd
+			continue
+		}
+
+		for _, ext := range fields[1:] {
+			types["."+strings.TrimPrefix(ext, ".")] = fields[0]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MIME types: %w", err)
+	}
+
+	return types, nil
+}
+
+// detectContentType looks up the extension in the configured and built-in MIME tables and the
+// system MIME database. Files with unknown extensions are sniffed.
+func (u *S3) detectContentType(file string, open OpenFunc, ext string) string {
+	if ext != "" {
+		lower := strings.ToLower(ext)
+
+		if contentType, ok := u.MIMETypes[lower]; ok {
+			return contentType
+		}
+
+		if contentType, ok := builtinMIMETypes[lower]; ok {
+			return contentType
+		}
+
+		if contentType := mime.TypeByExtension(ext); contentType != "" {
+			return contentType
+		}
+	}
+
+	f, err := openLocal(file, open)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffLength)
+
+	n, err := io.ReadFull(f, buf)
+	if err != nil && n == 0 {
+		return ""
+	}
+
+	return http.DetectContentType(buf[:n])
+}
+
+// withCharset adds the default charset to text content types without a charset.
+func (u *S3) withCharset(contentType string) string {
+	if u.DefaultCharset == "" || !strings.HasPrefix(contentType, "text/") {
+		return contentType
+	}
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["charset"] != "" {
+		return contentType
+	}
+
+	return contentType + "; charset=" + u.DefaultCharset
+}
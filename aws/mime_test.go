@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestS3_GetContentType(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "CNAME"), []byte("www.example.com\n"), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "logo"), []byte("\x89PNG\r\n\x1a\n"), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "app.wasm"), []byte("\x00asm"), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "data.custom"), []byte("{}"), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "notes.text"), []byte("hello"), 0o600)
+
+	tests := []struct {
+		name     string
+		s3       *S3
+		file     string
+		patterns map[string]string
+		want     string
+	}{
+		{
+			name: "pattern takes precedence",
+			s3:   &S3{},
+			file: "app.wasm",
+			patterns: map[string]string{
+				".wasm": "application/octet-stream",
+			},
+			want: "application/octet-stream",
+		},
+		{
+			name: "built-in type",
+			s3:   &S3{},
+			file: "app.wasm",
+			want: "application/wasm",
+		},
+		{
+			name: "custom type",
+			s3:   &S3{MIMETypes: map[string]string{".custom": "application/x-custom"}},
+			file: "data.custom",
+			want: "application/x-custom",
+		},
+		{
+			name: "sniff text without extension",
+			s3:   &S3{},
+			file: "CNAME",
+			want: "text/plain; charset=utf-8",
+		},
+		{
+			name: "sniff image without extension",
+			s3:   &S3{},
+			file: "logo",
+			want: "image/png",
+		},
+		{
+			name: "add default charset",
+			s3:   &S3{DefaultCharset: "utf-8", MIMETypes: map[string]string{".text": "text/plain"}},
+			file: "notes.text",
+			want: "text/plain; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.s3.getContentType(filepath.Join(dir, tt.file), nil, tt.patterns))
+		})
+	}
+}
+
+func TestLoadMIMETypes(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mime.types")
+	_ = os.WriteFile(path, []byte("# custom types\napplication/x-custom\tcustom cst\ntext/x-empty\n"), 0o600)
+
+	got, err := LoadMIMETypes(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{".custom": "application/x-custom", ".cst": "application/x-custom"}, got)
+}
@@ -22,6 +22,154 @@ func (_m *MockS3APIClient) EXPECT() *MockS3APIClient_Expecter {
 	return &MockS3APIClient_Expecter{mock: &_m.Mock}
 }
 
+// AbortMultipartUpload provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AbortMultipartUpload")
+	}
+
+	var r0 *s3.AbortMultipartUploadOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.AbortMultipartUploadInput, ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.AbortMultipartUploadInput, ...func(*s3.Options)) *s3.AbortMultipartUploadOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.AbortMultipartUploadOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.AbortMultipartUploadInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_AbortMultipartUpload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AbortMultipartUpload'
+type MockS3APIClient_AbortMultipartUpload_Call struct {
+	*mock.Call
+}
+
+// AbortMultipartUpload is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.AbortMultipartUploadInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) AbortMultipartUpload(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_AbortMultipartUpload_Call {
+	return &MockS3APIClient_AbortMultipartUpload_Call{Call: _e.mock.On("AbortMultipartUpload",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_AbortMultipartUpload_Call) Run(run func(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options))) *MockS3APIClient_AbortMultipartUpload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.AbortMultipartUploadInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_AbortMultipartUpload_Call) Return(_a0 *s3.AbortMultipartUploadOutput, _a1 error) *MockS3APIClient_AbortMultipartUpload_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_AbortMultipartUpload_Call) RunAndReturn(run func(context.Context, *s3.AbortMultipartUploadInput, ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)) *MockS3APIClient_AbortMultipartUpload_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CompleteMultipartUpload provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteMultipartUpload")
+	}
+
+	var r0 *s3.CompleteMultipartUploadOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.CompleteMultipartUploadInput, ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.CompleteMultipartUploadInput, ...func(*s3.Options)) *s3.CompleteMultipartUploadOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.CompleteMultipartUploadOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.CompleteMultipartUploadInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_CompleteMultipartUpload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompleteMultipartUpload'
+type MockS3APIClient_CompleteMultipartUpload_Call struct {
+	*mock.Call
+}
+
+// CompleteMultipartUpload is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.CompleteMultipartUploadInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) CompleteMultipartUpload(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_CompleteMultipartUpload_Call {
+	return &MockS3APIClient_CompleteMultipartUpload_Call{Call: _e.mock.On("CompleteMultipartUpload",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_CompleteMultipartUpload_Call) Run(run func(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options))) *MockS3APIClient_CompleteMultipartUpload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.CompleteMultipartUploadInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_CompleteMultipartUpload_Call) Return(_a0 *s3.CompleteMultipartUploadOutput, _a1 error) *MockS3APIClient_CompleteMultipartUpload_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_CompleteMultipartUpload_Call) RunAndReturn(run func(context.Context, *s3.CompleteMultipartUploadInput, ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)) *MockS3APIClient_CompleteMultipartUpload_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CopyObject provides a mock function with given fields: ctx, params, optFns
 func (_m *MockS3APIClient) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
 	_va := make([]interface{}, len(optFns))
@@ -39,18 +187,314 @@ func (_m *MockS3APIClient) CopyObject(ctx context.Context, params *s3.CopyObject
 
 	var r0 *s3.CopyObjectOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *s3.CopyObjectInput, ...func(*s3.Options)) (*s3.CopyObjectOutput, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.CopyObjectInput, ...func(*s3.Options)) (*s3.CopyObjectOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.CopyObjectInput, ...func(*s3.Options)) *s3.CopyObjectOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.CopyObjectOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.CopyObjectInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_CopyObject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CopyObject'
+type MockS3APIClient_CopyObject_Call struct {
+	*mock.Call
+}
+
+// CopyObject is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.CopyObjectInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) CopyObject(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_CopyObject_Call {
+	return &MockS3APIClient_CopyObject_Call{Call: _e.mock.On("CopyObject",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_CopyObject_Call) Run(run func(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options))) *MockS3APIClient_CopyObject_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.CopyObjectInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_CopyObject_Call) Return(_a0 *s3.CopyObjectOutput, _a1 error) *MockS3APIClient_CopyObject_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_CopyObject_Call) RunAndReturn(run func(context.Context, *s3.CopyObjectInput, ...func(*s3.Options)) (*s3.CopyObjectOutput, error)) *MockS3APIClient_CopyObject_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateMultipartUpload provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMultipartUpload")
+	}
+
+	var r0 *s3.CreateMultipartUploadOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.CreateMultipartUploadInput, ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.CreateMultipartUploadInput, ...func(*s3.Options)) *s3.CreateMultipartUploadOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.CreateMultipartUploadOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.CreateMultipartUploadInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_CreateMultipartUpload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateMultipartUpload'
+type MockS3APIClient_CreateMultipartUpload_Call struct {
+	*mock.Call
+}
+
+// CreateMultipartUpload is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.CreateMultipartUploadInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) CreateMultipartUpload(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_CreateMultipartUpload_Call {
+	return &MockS3APIClient_CreateMultipartUpload_Call{Call: _e.mock.On("CreateMultipartUpload",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_CreateMultipartUpload_Call) Run(run func(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options))) *MockS3APIClient_CreateMultipartUpload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.CreateMultipartUploadInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_CreateMultipartUpload_Call) Return(_a0 *s3.CreateMultipartUploadOutput, _a1 error) *MockS3APIClient_CreateMultipartUpload_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_CreateMultipartUpload_Call) RunAndReturn(run func(context.Context, *s3.CreateMultipartUploadInput, ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)) *MockS3APIClient_CreateMultipartUpload_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteObject provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteObject")
+	}
+
+	var r0 *s3.DeleteObjectOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) *s3.DeleteObjectOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.DeleteObjectOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_DeleteObject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteObject'
+type MockS3APIClient_DeleteObject_Call struct {
+	*mock.Call
+}
+
+// DeleteObject is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.DeleteObjectInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) DeleteObject(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_DeleteObject_Call {
+	return &MockS3APIClient_DeleteObject_Call{Call: _e.mock.On("DeleteObject",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_DeleteObject_Call) Run(run func(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options))) *MockS3APIClient_DeleteObject_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.DeleteObjectInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_DeleteObject_Call) Return(_a0 *s3.DeleteObjectOutput, _a1 error) *MockS3APIClient_DeleteObject_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_DeleteObject_Call) RunAndReturn(run func(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)) *MockS3APIClient_DeleteObject_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteObjects provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteObjects")
+	}
+
+	var r0 *s3.DeleteObjectsOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.DeleteObjectsInput, ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.DeleteObjectsInput, ...func(*s3.Options)) *s3.DeleteObjectsOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.DeleteObjectsOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.DeleteObjectsInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_DeleteObjects_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteObjects'
+type MockS3APIClient_DeleteObjects_Call struct {
+	*mock.Call
+}
+
+// DeleteObjects is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.DeleteObjectsInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) DeleteObjects(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_DeleteObjects_Call {
+	return &MockS3APIClient_DeleteObjects_Call{Call: _e.mock.On("DeleteObjects",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_DeleteObjects_Call) Run(run func(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options))) *MockS3APIClient_DeleteObjects_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.DeleteObjectsInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_DeleteObjects_Call) Return(_a0 *s3.DeleteObjectsOutput, _a1 error) *MockS3APIClient_DeleteObjects_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_DeleteObjects_Call) RunAndReturn(run func(context.Context, *s3.DeleteObjectsInput, ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)) *MockS3APIClient_DeleteObjects_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetBucketAcl provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) GetBucketAcl(ctx context.Context, params *s3.GetBucketAclInput, optFns ...func(*s3.Options)) (*s3.GetBucketAclOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBucketAcl")
+	}
+
+	var r0 *s3.GetBucketAclOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.GetBucketAclInput, ...func(*s3.Options)) (*s3.GetBucketAclOutput, error)); ok {
 		return rf(ctx, params, optFns...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *s3.CopyObjectInput, ...func(*s3.Options)) *s3.CopyObjectOutput); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.GetBucketAclInput, ...func(*s3.Options)) *s3.GetBucketAclOutput); ok {
 		r0 = rf(ctx, params, optFns...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*s3.CopyObjectOutput)
+			r0 = ret.Get(0).(*s3.GetBucketAclOutput)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *s3.CopyObjectInput, ...func(*s3.Options)) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.GetBucketAclInput, ...func(*s3.Options)) error); ok {
 		r1 = rf(ctx, params, optFns...)
 	} else {
 		r1 = ret.Error(1)
@@ -59,21 +503,21 @@ func (_m *MockS3APIClient) CopyObject(ctx context.Context, params *s3.CopyObject
 	return r0, r1
 }
 
-// MockS3APIClient_CopyObject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CopyObject'
-type MockS3APIClient_CopyObject_Call struct {
+// MockS3APIClient_GetBucketAcl_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBucketAcl'
+type MockS3APIClient_GetBucketAcl_Call struct {
 	*mock.Call
 }
 
-// CopyObject is a helper method to define mock.On call
+// GetBucketAcl is a helper method to define mock.On call
 //   - ctx context.Context
-//   - params *s3.CopyObjectInput
+//   - params *s3.GetBucketAclInput
 //   - optFns ...func(*s3.Options)
-func (_e *MockS3APIClient_Expecter) CopyObject(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_CopyObject_Call {
-	return &MockS3APIClient_CopyObject_Call{Call: _e.mock.On("CopyObject",
+func (_e *MockS3APIClient_Expecter) GetBucketAcl(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_GetBucketAcl_Call {
+	return &MockS3APIClient_GetBucketAcl_Call{Call: _e.mock.On("GetBucketAcl",
 		append([]interface{}{ctx, params}, optFns...)...)}
 }
 
-func (_c *MockS3APIClient_CopyObject_Call) Run(run func(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options))) *MockS3APIClient_CopyObject_Call {
+func (_c *MockS3APIClient_GetBucketAcl_Call) Run(run func(ctx context.Context, params *s3.GetBucketAclInput, optFns ...func(*s3.Options))) *MockS3APIClient_GetBucketAcl_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		variadicArgs := make([]func(*s3.Options), len(args)-2)
 		for i, a := range args[2:] {
@@ -81,23 +525,23 @@ func (_c *MockS3APIClient_CopyObject_Call) Run(run func(ctx context.Context, par
 				variadicArgs[i] = a.(func(*s3.Options))
 			}
 		}
-		run(args[0].(context.Context), args[1].(*s3.CopyObjectInput), variadicArgs...)
+		run(args[0].(context.Context), args[1].(*s3.GetBucketAclInput), variadicArgs...)
 	})
 	return _c
 }
 
-func (_c *MockS3APIClient_CopyObject_Call) Return(_a0 *s3.CopyObjectOutput, _a1 error) *MockS3APIClient_CopyObject_Call {
+func (_c *MockS3APIClient_GetBucketAcl_Call) Return(_a0 *s3.GetBucketAclOutput, _a1 error) *MockS3APIClient_GetBucketAcl_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockS3APIClient_CopyObject_Call) RunAndReturn(run func(context.Context, *s3.CopyObjectInput, ...func(*s3.Options)) (*s3.CopyObjectOutput, error)) *MockS3APIClient_CopyObject_Call {
+func (_c *MockS3APIClient_GetBucketAcl_Call) RunAndReturn(run func(context.Context, *s3.GetBucketAclInput, ...func(*s3.Options)) (*s3.GetBucketAclOutput, error)) *MockS3APIClient_GetBucketAcl_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteObject provides a mock function with given fields: ctx, params, optFns
-func (_m *MockS3APIClient) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+// GetBucketOwnershipControls provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) GetBucketOwnershipControls(ctx context.Context, params *s3.GetBucketOwnershipControlsInput, optFns ...func(*s3.Options)) (*s3.GetBucketOwnershipControlsOutput, error) {
 	_va := make([]interface{}, len(optFns))
 	for _i := range optFns {
 		_va[_i] = optFns[_i]
@@ -108,23 +552,23 @@ func (_m *MockS3APIClient) DeleteObject(ctx context.Context, params *s3.DeleteOb
 	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteObject")
+		panic("no return value specified for GetBucketOwnershipControls")
 	}
 
-	var r0 *s3.DeleteObjectOutput
+	var r0 *s3.GetBucketOwnershipControlsOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.GetBucketOwnershipControlsInput, ...func(*s3.Options)) (*s3.GetBucketOwnershipControlsOutput, error)); ok {
 		return rf(ctx, params, optFns...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) *s3.DeleteObjectOutput); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.GetBucketOwnershipControlsInput, ...func(*s3.Options)) *s3.GetBucketOwnershipControlsOutput); ok {
 		r0 = rf(ctx, params, optFns...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*s3.DeleteObjectOutput)
+			r0 = ret.Get(0).(*s3.GetBucketOwnershipControlsOutput)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.GetBucketOwnershipControlsInput, ...func(*s3.Options)) error); ok {
 		r1 = rf(ctx, params, optFns...)
 	} else {
 		r1 = ret.Error(1)
@@ -133,21 +577,21 @@ func (_m *MockS3APIClient) DeleteObject(ctx context.Context, params *s3.DeleteOb
 	return r0, r1
 }
 
-// MockS3APIClient_DeleteObject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteObject'
-type MockS3APIClient_DeleteObject_Call struct {
+// MockS3APIClient_GetBucketOwnershipControls_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBucketOwnershipControls'
+type MockS3APIClient_GetBucketOwnershipControls_Call struct {
 	*mock.Call
 }
 
-// DeleteObject is a helper method to define mock.On call
+// GetBucketOwnershipControls is a helper method to define mock.On call
 //   - ctx context.Context
-//   - params *s3.DeleteObjectInput
+//   - params *s3.GetBucketOwnershipControlsInput
 //   - optFns ...func(*s3.Options)
-func (_e *MockS3APIClient_Expecter) DeleteObject(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_DeleteObject_Call {
-	return &MockS3APIClient_DeleteObject_Call{Call: _e.mock.On("DeleteObject",
+func (_e *MockS3APIClient_Expecter) GetBucketOwnershipControls(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_GetBucketOwnershipControls_Call {
+	return &MockS3APIClient_GetBucketOwnershipControls_Call{Call: _e.mock.On("GetBucketOwnershipControls",
 		append([]interface{}{ctx, params}, optFns...)...)}
 }
 
-func (_c *MockS3APIClient_DeleteObject_Call) Run(run func(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options))) *MockS3APIClient_DeleteObject_Call {
+func (_c *MockS3APIClient_GetBucketOwnershipControls_Call) Run(run func(ctx context.Context, params *s3.GetBucketOwnershipControlsInput, optFns ...func(*s3.Options))) *MockS3APIClient_GetBucketOwnershipControls_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		variadicArgs := make([]func(*s3.Options), len(args)-2)
 		for i, a := range args[2:] {
@@ -155,17 +599,17 @@ func (_c *MockS3APIClient_DeleteObject_Call) Run(run func(ctx context.Context, p
 				variadicArgs[i] = a.(func(*s3.Options))
 			}
 		}
-		run(args[0].(context.Context), args[1].(*s3.DeleteObjectInput), variadicArgs...)
+		run(args[0].(context.Context), args[1].(*s3.GetBucketOwnershipControlsInput), variadicArgs...)
 	})
 	return _c
 }
 
-func (_c *MockS3APIClient_DeleteObject_Call) Return(_a0 *s3.DeleteObjectOutput, _a1 error) *MockS3APIClient_DeleteObject_Call {
+func (_c *MockS3APIClient_GetBucketOwnershipControls_Call) Return(_a0 *s3.GetBucketOwnershipControlsOutput, _a1 error) *MockS3APIClient_GetBucketOwnershipControls_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockS3APIClient_DeleteObject_Call) RunAndReturn(run func(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)) *MockS3APIClient_DeleteObject_Call {
+func (_c *MockS3APIClient_GetBucketOwnershipControls_Call) RunAndReturn(run func(context.Context, *s3.GetBucketOwnershipControlsInput, ...func(*s3.Options)) (*s3.GetBucketOwnershipControlsOutput, error)) *MockS3APIClient_GetBucketOwnershipControls_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -318,6 +762,80 @@ func (_c *MockS3APIClient_GetObjectAcl_Call) RunAndReturn(run func(context.Conte
 	return _c
 }
 
+// GetObjectLockConfiguration provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) GetObjectLockConfiguration(ctx context.Context, params *s3.GetObjectLockConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetObjectLockConfigurationOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetObjectLockConfiguration")
+	}
+
+	var r0 *s3.GetObjectLockConfigurationOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.GetObjectLockConfigurationInput, ...func(*s3.Options)) (*s3.GetObjectLockConfigurationOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.GetObjectLockConfigurationInput, ...func(*s3.Options)) *s3.GetObjectLockConfigurationOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.GetObjectLockConfigurationOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.GetObjectLockConfigurationInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_GetObjectLockConfiguration_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetObjectLockConfiguration'
+type MockS3APIClient_GetObjectLockConfiguration_Call struct {
+	*mock.Call
+}
+
+// GetObjectLockConfiguration is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.GetObjectLockConfigurationInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) GetObjectLockConfiguration(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_GetObjectLockConfiguration_Call {
+	return &MockS3APIClient_GetObjectLockConfiguration_Call{Call: _e.mock.On("GetObjectLockConfiguration",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_GetObjectLockConfiguration_Call) Run(run func(ctx context.Context, params *s3.GetObjectLockConfigurationInput, optFns ...func(*s3.Options))) *MockS3APIClient_GetObjectLockConfiguration_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.GetObjectLockConfigurationInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_GetObjectLockConfiguration_Call) Return(_a0 *s3.GetObjectLockConfigurationOutput, _a1 error) *MockS3APIClient_GetObjectLockConfiguration_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_GetObjectLockConfiguration_Call) RunAndReturn(run func(context.Context, *s3.GetObjectLockConfigurationInput, ...func(*s3.Options)) (*s3.GetObjectLockConfigurationOutput, error)) *MockS3APIClient_GetObjectLockConfiguration_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // HeadObject provides a mock function with given fields: ctx, params, optFns
 func (_m *MockS3APIClient) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
 	_va := make([]interface{}, len(optFns))
@@ -392,6 +910,80 @@ func (_c *MockS3APIClient_HeadObject_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// ListObjectVersions provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) ListObjectVersions(ctx context.Context, params *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListObjectVersions")
+	}
+
+	var r0 *s3.ListObjectVersionsOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.ListObjectVersionsInput, ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.ListObjectVersionsInput, ...func(*s3.Options)) *s3.ListObjectVersionsOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.ListObjectVersionsOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.ListObjectVersionsInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_ListObjectVersions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListObjectVersions'
+type MockS3APIClient_ListObjectVersions_Call struct {
+	*mock.Call
+}
+
+// ListObjectVersions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.ListObjectVersionsInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) ListObjectVersions(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_ListObjectVersions_Call {
+	return &MockS3APIClient_ListObjectVersions_Call{Call: _e.mock.On("ListObjectVersions",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_ListObjectVersions_Call) Run(run func(ctx context.Context, params *s3.ListObjectVersionsInput, optFns ...func(*s3.Options))) *MockS3APIClient_ListObjectVersions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.ListObjectVersionsInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_ListObjectVersions_Call) Return(_a0 *s3.ListObjectVersionsOutput, _a1 error) *MockS3APIClient_ListObjectVersions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_ListObjectVersions_Call) RunAndReturn(run func(context.Context, *s3.ListObjectVersionsInput, ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error)) *MockS3APIClient_ListObjectVersions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListObjects provides a mock function with given fields: ctx, params, optFns
 func (_m *MockS3APIClient) ListObjects(ctx context.Context, params *s3.ListObjectsInput, optFns ...func(*s3.Options)) (*s3.ListObjectsOutput, error) {
 	_va := make([]interface{}, len(optFns))
@@ -466,6 +1058,80 @@ func (_c *MockS3APIClient_ListObjects_Call) RunAndReturn(run func(context.Contex
 	return _c
 }
 
+// ListParts provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) ListParts(ctx context.Context, params *s3.ListPartsInput, optFns ...func(*s3.Options)) (*s3.ListPartsOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListParts")
+	}
+
+	var r0 *s3.ListPartsOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.ListPartsInput, ...func(*s3.Options)) (*s3.ListPartsOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.ListPartsInput, ...func(*s3.Options)) *s3.ListPartsOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.ListPartsOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.ListPartsInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_ListParts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListParts'
+type MockS3APIClient_ListParts_Call struct {
+	*mock.Call
+}
+
+// ListParts is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.ListPartsInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) ListParts(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_ListParts_Call {
+	return &MockS3APIClient_ListParts_Call{Call: _e.mock.On("ListParts",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_ListParts_Call) Run(run func(ctx context.Context, params *s3.ListPartsInput, optFns ...func(*s3.Options))) *MockS3APIClient_ListParts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.ListPartsInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_ListParts_Call) Return(_a0 *s3.ListPartsOutput, _a1 error) *MockS3APIClient_ListParts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_ListParts_Call) RunAndReturn(run func(context.Context, *s3.ListPartsInput, ...func(*s3.Options)) (*s3.ListPartsOutput, error)) *MockS3APIClient_ListParts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // PutObject provides a mock function with given fields: ctx, params, optFns
 func (_m *MockS3APIClient) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
 	_va := make([]interface{}, len(optFns))
@@ -540,6 +1206,80 @@ func (_c *MockS3APIClient_PutObject_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
+// UploadPart provides a mock function with given fields: ctx, params, optFns
+func (_m *MockS3APIClient) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UploadPart")
+	}
+
+	var r0 *s3.UploadPartOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.UploadPartInput, ...func(*s3.Options)) (*s3.UploadPartOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *s3.UploadPartInput, ...func(*s3.Options)) *s3.UploadPartOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.UploadPartOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *s3.UploadPartInput, ...func(*s3.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockS3APIClient_UploadPart_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UploadPart'
+type MockS3APIClient_UploadPart_Call struct {
+	*mock.Call
+}
+
+// UploadPart is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *s3.UploadPartInput
+//   - optFns ...func(*s3.Options)
+func (_e *MockS3APIClient_Expecter) UploadPart(ctx interface{}, params interface{}, optFns ...interface{}) *MockS3APIClient_UploadPart_Call {
+	return &MockS3APIClient_UploadPart_Call{Call: _e.mock.On("UploadPart",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockS3APIClient_UploadPart_Call) Run(run func(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options))) *MockS3APIClient_UploadPart_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*s3.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*s3.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*s3.UploadPartInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockS3APIClient_UploadPart_Call) Return(_a0 *s3.UploadPartOutput, _a1 error) *MockS3APIClient_UploadPart_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockS3APIClient_UploadPart_Call) RunAndReturn(run func(context.Context, *s3.UploadPartInput, ...func(*s3.Options)) (*s3.UploadPartOutput, error)) *MockS3APIClient_UploadPart_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockS3APIClient creates a new instance of MockS3APIClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockS3APIClient(t interface {
@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+func TestS3_UploadSourceObject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		copyErr error
+		upload  bool
+		wantErr error
+	}{
+		{
+			name: "copy identical content",
+		},
+		{
+			name:    "upload if source was removed",
+			copyErr: &smithy.GenericAPIError{Code: "NoSuchKey"},
+			upload:  true,
+		},
+		{
+			name:    "upload if copy was rejected",
+			copyErr: &smithy.GenericAPIError{Code: "InvalidRequest"},
+			upload:  true,
+		},
+		{
+			name:    "error on conflict",
+			copyErr: &smithy.GenericAPIError{Code: errCodePreconditionFailed},
+			wantErr: ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(nil, &types.NotFound{})
+			mockS3Client.On("CopyObject", mock.Anything, mock.MatchedBy(func(input *s3.CopyObjectInput) bool {
+				return aws.ToString(input.CopySource) == "test-bucket/old/file.txt" &&
+					aws.ToString(input.Key) == "new/file.txt" &&
+					aws.ToString(input.ContentType) == "text/plain" &&
+					input.MetadataDirective == types.MetadataDirectiveReplace
+			})).Return(&s3.CopyObjectOutput{}, tt.copyErr)
+
+			if tt.upload {
+				mockS3Client.On("PutObject", mock.Anything, mock.Anything).Return(&s3.PutObjectOutput{}, nil)
+			}
+
+			u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+
+			err := u.Upload(t.Context(), S3UploadOptions{
+				LocalFilePath:   createTempFile(t, "file.txt"),
+				RemoteObjectKey: "new/file.txt",
+				ContentType:     map[string]string{".txt": "text/plain"},
+				SourceObjectKey: "old/file.txt",
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestS3_ListObjects(t *testing.T) {
+	t.Parallel()
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("ListObjects", mock.Anything, mock.MatchedBy(func(input *s3.ListObjectsInput) bool {
+		return input.Marker == nil
+	})).Return(&s3.ListObjectsOutput{
+		Contents:    []types.Object{{Key: aws.String("site/a.txt"), ETag: aws.String(`"aaa"`), Size: aws.Int64(3)}},
+		IsTruncated: aws.Bool(true),
+	}, nil)
+	mockS3Client.On("ListObjects", mock.Anything, mock.MatchedBy(func(input *s3.ListObjectsInput) bool {
+		return aws.ToString(input.Marker) == "site/a.txt"
+	})).Return(&s3.ListObjectsOutput{
+		Contents:    []types.Object{{Key: aws.String("site/b.txt"), ETag: aws.String(`"bbb-2"`)}},
+		IsTruncated: aws.Bool(false),
+	}, nil)
+
+	u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+
+	got, err := u.ListObjects(t.Context(), S3ListOptions{Path: "site"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []S3Object{
+		{Key: "site/a.txt", ETag: "aaa", Size: 3},
+		{Key: "site/b.txt", ETag: "bbb-2"},
+	}, got)
+}
@@ -0,0 +1,345 @@
+package aws
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/rs/zerolog/log"
+)
+
+// MinPartSize is the smallest part size accepted by S3 for multipart uploads.
+const MinPartSize = 5 * 1024 * 1024
+
+var (
+	ErrInvalidSize      = errors.New("invalid size")
+	ErrPartSizeTooSmall = errors.New("part size too small")
+)
+
+// MultipartState records the progress of a multipart upload.
+type MultipartState struct {
+	UploadID  string            `json:"uploadId"`
+	PartSize  int64             `json:"partSize"`
+	Algorithm ChecksumAlgorithm `json:"algorithm,omitempty"`
+	Parts     []MultipartPart   `json:"parts"`
+}
+
+// MultipartPart is a finished part of a multipart upload.
+type MultipartPart struct {
+	Number   int32  `json:"number"`
+	ETag     string `json:"etag"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// MultipartStore persists the state of a single multipart upload so that an interrupted
+// upload can be resumed from its last finished part.
+type MultipartStore interface {
+	Load() *MultipartState
+	Save(state *MultipartState)
+}
+
+// ParseSize parses a size like `64MiB` into bytes. An empty value returns zero.
+func ParseSize(value string) (int64, error) {
+	n, ok := parseBytes(value)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidSize, value)
+	}
+
+	return n, nil
+}
+
+// ParsePartSize parses the multipart part size and ensures it is accepted by S3.
+// An empty value returns zero, which disables multipart uploads.
+func ParsePartSize(value string) (int64, error) {
+	size, err := ParseSize(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if size > 0 && size < MinPartSize {
+		return 0, fmt.Errorf("%w: %s", ErrPartSizeTooSmall, value)
+	}
+
+	return size, nil
+}
+
+// useMultipart reports whether a file of the given size is uploaded in parts.
+func (u *S3) useMultipart(size int64) bool {
+	return u.PartSize > 0 && size > u.PartSize
+}
+
+// put uploads the file with the given input, either as a single request or in parts.
+func (u *S3) put(ctx context.Context, file LocalFile, input *s3.PutObjectInput, store MultipartStore) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if !u.useMultipart(info.Size()) {
+		input.Body = file
+		input.ChecksumAlgorithm = u.ChecksumAlgorithm.sdk()
+
+		_, err = u.client.PutObject(ctx, input)
+
+		return err
+	}
+
+	return u.putMultipart(ctx, file, info.Size(), input, store)
+}
+
+// putMultipart uploads the file in parts. Finished parts are recorded in the store and
+// skipped when the upload is resumed.
+func (u *S3) putMultipart(
+	ctx context.Context, file LocalFile, size int64, input *s3.PutObjectInput, store MultipartStore,
+) error {
+	state := u.resumeMultipart(ctx, input, store)
+	if state == nil {
+		out, err := u.client.CreateMultipartUpload(ctx, u.createMultipartInput(input))
+		if err != nil {
+			return err
+		}
+
+		state = &MultipartState{UploadID: *out.UploadId, PartSize: u.PartSize, Algorithm: u.ChecksumAlgorithm}
+		saveMultipart(store, state)
+	}
+
+	done := make(map[int32]bool, len(state.Parts))
+	for _, part := range state.Parts {
+		done[part.Number] = true
+	}
+
+	for number, offset := int32(1), int64(0); offset < size; number, offset = number+1, offset+u.PartSize {
+		if done[number] {
+			continue
+		}
+
+		out, err := u.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:            input.Bucket,
+			Key:               input.Key,
+			UploadId:          &state.UploadID,
+			PartNumber:        aws.Int32(number),
+			Body:              io.NewSectionReader(file, offset, min(u.PartSize, size-offset)),
+			ChecksumAlgorithm: u.ChecksumAlgorithm.sdk(),
+		})
+		if err != nil {
+			return u.failMultipart(ctx, input, state, store, err)
+		}
+
+		state.Parts = append(state.Parts, MultipartPart{
+			Number:   number,
+			ETag:     aws.ToString(out.ETag),
+			Checksum: u.ChecksumAlgorithm.fromPart(out),
+		})
+		saveMultipart(store, state)
+	}
+
+	sort.Slice(state.Parts, func(i, j int) bool {
+		return state.Parts[i].Number < state.Parts[j].Number
+	})
+
+	parts := make([]types.CompletedPart, 0, len(state.Parts))
+	for _, part := range state.Parts {
+		parts = append(parts, u.ChecksumAlgorithm.completedPart(part))
+	}
+
+	_, err := u.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          input.Bucket,
+		Key:             input.Key,
+		UploadId:        &state.UploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		IfMatch:         input.IfMatch,
+		IfNoneMatch:     input.IfNoneMatch,
+	})
+	if err != nil {
+		return u.failMultipart(ctx, input, state, store, err)
+	}
+
+	saveMultipart(store, nil)
+
+	return nil
+}
+
+// resumeMultipart returns the stored state of an unfinished upload. The finished parts are
+// taken from the bucket, parts that were uploaded but not yet recorded are reused as well.
+func (u *S3) resumeMultipart(ctx context.Context, put *s3.PutObjectInput, store MultipartStore) *MultipartState {
+	if store == nil {
+		return nil
+	}
+
+	state := store.Load()
+	if state == nil || state.UploadID == "" {
+		return nil
+	}
+
+	if state.PartSize != u.PartSize || state.Algorithm != u.ChecksumAlgorithm {
+		log.Debug().Msgf("unable to resume upload of '%s' with different part settings, starting over", *put.Key)
+		u.dropMultipart(ctx, *put.Key, state.UploadID)
+
+		return nil
+	}
+
+	resumed := &MultipartState{UploadID: state.UploadID, PartSize: state.PartSize, Algorithm: state.Algorithm}
+	input := &s3.ListPartsInput{
+		Bucket:   put.Bucket,
+		Key:      put.Key,
+		UploadId: &state.UploadID,
+	}
+
+	for {
+		resp, err := u.client.ListParts(ctx, input)
+		if err != nil {
+			log.Debug().Msgf("unable to resume upload of '%s', starting over: %v", *put.Key, err)
+			u.dropMultipart(ctx, *put.Key, state.UploadID)
+
+			return nil
+		}
+
+		for _, part := range resp.Parts {
+			resumed.Parts = append(resumed.Parts, MultipartPart{
+				Number:   aws.ToInt32(part.PartNumber),
+				ETag:     aws.ToString(part.ETag),
+				Checksum: u.ChecksumAlgorithm.fromListedPart(part),
+			})
+		}
+
+		if !aws.ToBool(resp.IsTruncated) {
+			break
+		}
+
+		input.PartNumberMarker = resp.NextPartNumberMarker
+	}
+
+	log.Debug().Msgf("resuming upload of '%s' with %d finished parts", *put.Key, len(resumed.Parts))
+
+	return resumed
+}
+
+// failMultipart aborts the upload unless its state is kept for a later resume. Uploads that
+// were rejected by the store can not be resumed and are always aborted.
+func (u *S3) failMultipart(
+	ctx context.Context, input *s3.PutObjectInput, state *MultipartState, store MultipartStore, err error,
+) error {
+	if store != nil && resumable(err) {
+		return err
+	}
+
+	saveMultipart(store, nil)
+
+	return errors.Join(err, u.AbortMultipart(ctx, *input.Key, state.UploadID))
+}
+
+// AbortMultipart aborts an unfinished multipart upload and removes its parts. The abort is
+// sent even if the context is already canceled.
+func (u *S3) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	_, err := u.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   &u.Bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+	})
+
+	return err
+}
+
+// dropMultipart aborts a stored upload that is started over instead of being resumed.
+func (u *S3) dropMultipart(ctx context.Context, key, uploadID string) {
+	if err := u.AbortMultipart(ctx, key, uploadID); err != nil {
+		log.Debug().Msgf("failed to abort upload of '%s': %v", key, err)
+	}
+}
+
+// resumable reports whether an upload that failed with the error can be resumed later. Interrupted
+// uploads and server errors are resumable, requests rejected by the store are not.
+func resumable(err error) bool {
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		status := respErr.HTTPStatusCode()
+
+		return status >= http.StatusInternalServerError ||
+			status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+	}
+
+	var apiErr smithy.APIError
+
+	return !errors.As(err, &apiErr) || apiErr.ErrorFault() != smithy.FaultClient
+}
+
+func saveMultipart(store MultipartStore, state *MultipartState) {
+	if store != nil {
+		store.Save(state)
+	}
+}
+
+// createMultipartInput converts the input of a single request upload to a multipart upload.
+func (u *S3) createMultipartInput(input *s3.PutObjectInput) *s3.CreateMultipartUploadInput {
+	multipart := &s3.CreateMultipartUploadInput{
+		Bucket:          input.Bucket,
+		Key:             input.Key,
+		ACL:             input.ACL,
+		ContentType:     input.ContentType,
+		ContentEncoding: input.ContentEncoding,
+		CacheControl:    input.CacheControl,
+		Metadata:        input.Metadata,
+
+		ContentDisposition: input.ContentDisposition,
+		ContentLanguage:    input.ContentLanguage,
+		Expires:            input.Expires,
+
+		WebsiteRedirectLocation: input.WebsiteRedirectLocation,
+
+		ObjectLockMode:            input.ObjectLockMode,
+		ObjectLockRetainUntilDate: input.ObjectLockRetainUntilDate,
+		ObjectLockLegalHoldStatus: input.ObjectLockLegalHoldStatus,
+	}
+
+	if u.ChecksumAlgorithm != "" {
+		multipart.ChecksumAlgorithm = u.ChecksumAlgorithm.sdk()
+		multipart.ChecksumType = u.ChecksumAlgorithm.checksumType()
+	}
+
+	return multipart
+}
+
+// fileETag calculates the ETag S3 assigns to the file. Files uploaded in parts get the
+// MD5 of the concatenated part MD5s followed by the number of parts.
+func (u *S3) fileETag(file LocalFile) (string, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if !u.useMultipart(info.Size()) {
+		//nolint:gosec
+		hash := md5.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	}
+
+	//nolint:gosec
+	sums := md5.New()
+	parts := 0
+
+	for offset := int64(0); offset < info.Size(); offset += u.PartSize {
+		//nolint:gosec
+		hash := md5.New()
+		if _, err := io.Copy(hash, io.NewSectionReader(file, offset, u.PartSize)); err != nil {
+			return "", err
+		}
+
+		sums.Write(hash.Sum(nil))
+		parts++
+	}
+
+	return fmt.Sprintf("%x-%d", sums.Sum(nil), parts), nil
+}
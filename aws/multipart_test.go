@@ -0,0 +1,300 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+type memoryMultipartStore struct {
+	state *MultipartState
+	saves int
+}
+
+func (m *memoryMultipartStore) Load() *MultipartState {
+	return m.state
+}
+
+func (m *memoryMultipartStore) Save(state *MultipartState) {
+	m.state = state
+	m.saves++
+}
+
+func createLargeTempFile(t *testing.T, size int) *os.File {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "large.bin")
+	_ = os.WriteFile(name, make([]byte, size), 0o600)
+
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { file.Close() })
+
+	return file
+}
+
+func TestParsePartSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr error
+	}{
+		{
+			name:  "empty value disables multipart",
+			value: "",
+			want:  0,
+		},
+		{
+			name:  "binary unit",
+			value: "64MiB",
+			want:  64 * 1024 * 1024,
+		},
+		{
+			name:    "error on part size below minimum",
+			value:   "1MiB",
+			wantErr: ErrPartSizeTooSmall,
+		},
+		{
+			name:    "error on invalid size",
+			value:   "large",
+			wantErr: ErrInvalidSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParsePartSize(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestS3_PutMultipart(t *testing.T) {
+	t.Parallel()
+
+	t.Run("upload all parts of a new upload", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("CreateMultipartUpload", mock.Anything, mock.Anything).
+			Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil)
+		mockS3Client.On("UploadPart", mock.Anything, mock.Anything).
+			Return(&s3.UploadPartOutput{ETag: aws.String("etag")}, nil).Times(3)
+		mockS3Client.On("CompleteMultipartUpload", mock.Anything, mock.MatchedBy(func(in *s3.CompleteMultipartUploadInput) bool {
+			return *in.UploadId == "upload-1" && len(in.MultipartUpload.Parts) == 3
+		})).Return(&s3.CompleteMultipartUploadOutput{}, nil)
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", PartSize: MinPartSize}
+		store := &memoryMultipartStore{}
+
+		err := u.put(t.Context(), createLargeTempFile(t, 2*MinPartSize+1), &s3.PutObjectInput{
+			Bucket: aws.String("test-bucket"),
+			Key:    aws.String("large.bin"),
+		}, store)
+
+		assert.NoError(t, err)
+		assert.Nil(t, store.state)
+		assert.Equal(t, 5, store.saves)
+	})
+
+	t.Run("resume upload from finished parts", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("ListParts", mock.Anything, mock.Anything).Return(&s3.ListPartsOutput{
+			Parts: []types.Part{
+				{PartNumber: aws.Int32(1), ETag: aws.String("etag-1")},
+				{PartNumber: aws.Int32(2), ETag: aws.String("etag-2")},
+			},
+		}, nil)
+		mockS3Client.On("UploadPart", mock.Anything, mock.MatchedBy(func(in *s3.UploadPartInput) bool {
+			return *in.PartNumber == 3
+		})).Return(&s3.UploadPartOutput{ETag: aws.String("etag-3")}, nil).Once()
+		mockS3Client.On("CompleteMultipartUpload", mock.Anything, mock.Anything).
+			Return(&s3.CompleteMultipartUploadOutput{}, nil)
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", PartSize: MinPartSize}
+		store := &memoryMultipartStore{state: &MultipartState{UploadID: "upload-1", PartSize: MinPartSize}}
+
+		err := u.put(t.Context(), createLargeTempFile(t, 2*MinPartSize+1), &s3.PutObjectInput{
+			Bucket: aws.String("test-bucket"),
+			Key:    aws.String("large.bin"),
+		}, store)
+
+		assert.NoError(t, err)
+		assert.Nil(t, store.state)
+	})
+
+	t.Run("keep state of failed upload with store", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("CreateMultipartUpload", mock.Anything, mock.Anything).
+			Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil)
+		mockS3Client.On("UploadPart", mock.Anything, mock.Anything).
+			Return(&s3.UploadPartOutput{ETag: aws.String("etag-1")}, nil).Once()
+		mockS3Client.On("UploadPart", mock.Anything, mock.Anything).
+			Return(&s3.UploadPartOutput{}, ErrPutObject).Once()
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", PartSize: MinPartSize}
+		store := &memoryMultipartStore{}
+
+		err := u.put(t.Context(), createLargeTempFile(t, 2*MinPartSize+1), &s3.PutObjectInput{
+			Bucket: aws.String("test-bucket"),
+			Key:    aws.String("large.bin"),
+		}, store)
+
+		assert.ErrorIs(t, err, ErrPutObject)
+		assert.Equal(t, "upload-1", store.state.UploadID)
+		assert.Len(t, store.state.Parts, 1)
+	})
+
+	t.Run("abort upload rejected by the store", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("CreateMultipartUpload", mock.Anything, mock.Anything).
+			Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil)
+		mockS3Client.On("UploadPart", mock.Anything, mock.Anything).
+			Return(&s3.UploadPartOutput{}, &smithy.GenericAPIError{Code: "AccessDenied", Fault: smithy.FaultClient}).Once()
+		mockS3Client.On("AbortMultipartUpload", mock.Anything, mock.MatchedBy(func(in *s3.AbortMultipartUploadInput) bool {
+			return *in.UploadId == "upload-1"
+		})).Return(&s3.AbortMultipartUploadOutput{}, nil)
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", PartSize: MinPartSize}
+		store := &memoryMultipartStore{}
+
+		err := u.put(t.Context(), createLargeTempFile(t, 2*MinPartSize+1), &s3.PutObjectInput{
+			Bucket: aws.String("test-bucket"),
+			Key:    aws.String("large.bin"),
+		}, store)
+
+		assert.Error(t, err)
+		assert.Nil(t, store.state)
+	})
+
+	t.Run("abort stored upload with different part size", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("AbortMultipartUpload", mock.Anything, mock.MatchedBy(func(in *s3.AbortMultipartUploadInput) bool {
+			return *in.UploadId == "upload-1"
+		})).Return(&s3.AbortMultipartUploadOutput{}, nil).Once()
+		mockS3Client.On("CreateMultipartUpload", mock.Anything, mock.Anything).
+			Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-2")}, nil)
+		mockS3Client.On("UploadPart", mock.Anything, mock.Anything).
+			Return(&s3.UploadPartOutput{ETag: aws.String("etag")}, nil).Times(3)
+		mockS3Client.On("CompleteMultipartUpload", mock.Anything, mock.MatchedBy(func(in *s3.CompleteMultipartUploadInput) bool {
+			return *in.UploadId == "upload-2"
+		})).Return(&s3.CompleteMultipartUploadOutput{}, nil)
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", PartSize: MinPartSize}
+		store := &memoryMultipartStore{state: &MultipartState{UploadID: "upload-1", PartSize: 2 * MinPartSize}}
+
+		err := u.put(t.Context(), createLargeTempFile(t, 2*MinPartSize+1), &s3.PutObjectInput{
+			Bucket: aws.String("test-bucket"),
+			Key:    aws.String("large.bin"),
+		}, store)
+
+		assert.NoError(t, err)
+		assert.Nil(t, store.state)
+	})
+
+	t.Run("send checksums with locked parts", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("CreateMultipartUpload", mock.Anything, mock.MatchedBy(func(in *s3.CreateMultipartUploadInput) bool {
+			return in.ObjectLockLegalHoldStatus == types.ObjectLockLegalHoldStatusOn &&
+				in.ChecksumAlgorithm == types.ChecksumAlgorithmCrc32c
+		})).Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil)
+		mockS3Client.On("UploadPart", mock.Anything, mock.MatchedBy(func(in *s3.UploadPartInput) bool {
+			return in.ChecksumAlgorithm == types.ChecksumAlgorithmCrc32c
+		})).Return(&s3.UploadPartOutput{ETag: aws.String("etag"), ChecksumCRC32C: aws.String("AAAAAA==")}, nil).Times(3)
+		mockS3Client.On("CompleteMultipartUpload", mock.Anything, mock.Anything).
+			Return(&s3.CompleteMultipartUploadOutput{}, nil)
+
+		u := &S3{
+			client:            mockS3Client,
+			Bucket:            "test-bucket",
+			PartSize:          MinPartSize,
+			ChecksumAlgorithm: ChecksumAlgorithmCRC32C,
+		}
+		input := &s3.PutObjectInput{
+			Bucket: aws.String("test-bucket"),
+			Key:    aws.String("large.bin"),
+		}
+		(&ObjectLock{LegalHold: true}).applyPut(input)
+
+		assert.NoError(t, u.put(t.Context(), createLargeTempFile(t, 2*MinPartSize+1), input, nil))
+	})
+
+	t.Run("abort failed upload without store", func(t *testing.T) {
+		t.Parallel()
+
+		mockS3Client := mocks.NewMockS3APIClient(t)
+		mockS3Client.On("CreateMultipartUpload", mock.Anything, mock.Anything).
+			Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil)
+		mockS3Client.On("UploadPart", mock.Anything, mock.Anything).
+			Return(&s3.UploadPartOutput{}, ErrPutObject).Once()
+		mockS3Client.On("AbortMultipartUpload", mock.Anything, mock.Anything).
+			Return(&s3.AbortMultipartUploadOutput{}, nil)
+
+		u := &S3{client: mockS3Client, Bucket: "test-bucket", PartSize: MinPartSize}
+
+		err := u.put(t.Context(), createLargeTempFile(t, 2*MinPartSize+1), &s3.PutObjectInput{
+			Bucket: aws.String("test-bucket"),
+			Key:    aws.String("large.bin"),
+		}, nil)
+
+		assert.ErrorIs(t, err, ErrPutObject)
+	})
+}
+
+func TestS3_FileETag(t *testing.T) {
+	t.Parallel()
+
+	t.Run("md5 of single part file", func(t *testing.T) {
+		t.Parallel()
+
+		file, _ := os.Open(createTempFile(t, "file.txt"))
+		defer file.Close()
+
+		got, err := (&S3{}).fileETag(file)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", got)
+	})
+
+	t.Run("md5 of part md5s for multipart file", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := (&S3{PartSize: MinPartSize}).fileETag(createLargeTempFile(t, 2*MinPartSize+1))
+
+		assert.NoError(t, err)
+		assert.Regexp(t, "^[0-9a-f]{32}-3$", got)
+	})
+}
@@ -0,0 +1,192 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/rs/zerolog/log"
+)
+
+type ObjectLockMode string
+
+const (
+	// ObjectLockGovernance allows users with special permissions to overwrite or delete locked objects.
+	ObjectLockGovernance ObjectLockMode = "GOVERNANCE"
+	// ObjectLockCompliance prevents all users from overwriting or deleting locked objects.
+	ObjectLockCompliance ObjectLockMode = "COMPLIANCE"
+)
+
+// errCodeObjectLockConfigurationNotFound is returned for buckets without Object Lock.
+const errCodeObjectLockConfigurationNotFound = "ObjectLockConfigurationNotFoundError"
+
+// errCodeAccessDenied is returned if the credentials lack the permission for a request.
+const errCodeAccessDenied = "AccessDenied"
+
+// notSupportedErrorCodes are returned by stores that do not implement an API.
+var notSupportedErrorCodes = map[string]bool{
+	"NotImplemented":   true,
+	"MethodNotAllowed": true,
+}
+
+const (
+	day  = 24 * time.Hour
+	year = 365 * day
+)
+
+var (
+	ErrInvalidObjectLockMode = errors.New("invalid object lock mode")
+	ErrInvalidRetention      = errors.New("invalid retention")
+	ErrObjectLocked          = errors.New("object is locked")
+)
+
+func (om *ObjectLockMode) Set(value string) error {
+	switch ObjectLockMode(strings.ToUpper(value)) {
+	case ObjectLockGovernance, ObjectLockCompliance:
+		*om = ObjectLockMode(strings.ToUpper(value))
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidObjectLockMode, value)
+	}
+}
+
+func (om *ObjectLockMode) String() string {
+	return string(*om)
+}
+
+// ObjectLock is the retention and legal hold applied to uploaded objects.
+type ObjectLock struct {
+	Mode        ObjectLockMode
+	RetainUntil time.Time
+	LegalHold   bool
+}
+
+// ParseRetention parses a retention period like `7y`, `30d` or `12h`. Besides the units
+// supported by time.ParseDuration, `d` are days and `y` are years of 365 days.
+func ParseRetention(value string) (time.Duration, error) {
+	var unit time.Duration
+
+	switch {
+	case strings.HasSuffix(value, "y"):
+		unit = year
+	case strings.HasSuffix(value, "d"):
+		unit = day
+	default:
+		retention, err := time.ParseDuration(value)
+		if err != nil || retention <= 0 {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidRetention, value)
+		}
+
+		return retention, nil
+	}
+
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidRetention, value)
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+// applyPut sets the lock of an uploaded object. A nil lock leaves the input unchanged.
+func (l *ObjectLock) applyPut(input *s3.PutObjectInput) {
+	if l == nil {
+		return
+	}
+
+	if l.Mode != "" {
+		input.ObjectLockMode = types.ObjectLockMode(l.Mode)
+		input.ObjectLockRetainUntilDate = &l.RetainUntil
+	}
+
+	if l.LegalHold {
+		input.ObjectLockLegalHoldStatus = types.ObjectLockLegalHoldStatusOn
+	}
+}
+
+// applyCopy sets the lock of a copied object. A nil lock leaves the input unchanged.
+func (l *ObjectLock) applyCopy(input *s3.CopyObjectInput) {
+	if l == nil {
+		return
+	}
+
+	if l.Mode != "" {
+		input.ObjectLockMode = types.ObjectLockMode(l.Mode)
+		input.ObjectLockRetainUntilDate = &l.RetainUntil
+	}
+
+	if l.LegalHold {
+		input.ObjectLockLegalHoldStatus = types.ObjectLockLegalHoldStatusOn
+	}
+}
+
+// checkObjectLock returns an error if the object is under legal hold or its retention has not expired.
+func checkObjectLock(key string, head *s3.HeadObjectOutput, now time.Time) error {
+	if head.ObjectLockLegalHoldStatus == types.ObjectLockLegalHoldStatusOn {
+		return fmt.Errorf("%w: '%s' is under legal hold", ErrObjectLocked, key)
+	}
+
+	if head.ObjectLockRetainUntilDate != nil && head.ObjectLockRetainUntilDate.After(now) {
+		return fmt.Errorf(
+			"%w: '%s' is retained in %s mode until %s",
+			ErrObjectLocked, key, head.ObjectLockMode, head.ObjectLockRetainUntilDate.Format(time.RFC3339),
+		)
+	}
+
+	return nil
+}
+
+// checkObjectLock returns an error if locks are checked and the existing object is locked.
+func (u *S3) checkObjectLock(key string, head *s3.HeadObjectOutput) error {
+	if !u.CheckObjectLock {
+		return nil
+	}
+
+	return checkObjectLock(key, head, time.Now())
+}
+
+// notSupported reports whether the store does not implement the requested API.
+func notSupported(err error) bool {
+	var apiErr smithy.APIError
+
+	return errors.As(err, &apiErr) && notSupportedErrorCodes[apiErr.ErrorCode()]
+}
+
+// DetectObjectLock reports whether Object Lock is enabled for the bucket. Objects are assumed
+// to be locked if the store does not implement the Object Lock configuration, and unlocked if
+// the configuration may not be read.
+func (u *S3) DetectObjectLock(ctx context.Context) (bool, error) {
+	resp, err := u.client.GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: &u.Bucket,
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == errCodeObjectLockConfigurationNotFound {
+			return false, nil
+		}
+
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == errCodeAccessDenied {
+			log.Warn().Msgf("unable to read object lock configuration, locks are not checked: %v", err)
+
+			return false, nil
+		}
+
+		if notSupported(err) {
+			log.Debug().Msgf("object lock configuration not supported, checking locks before deletes: %v", err)
+
+			return true, nil
+		}
+
+		return false, err
+	}
+
+	return resp.ObjectLockConfiguration != nil &&
+		resp.ObjectLockConfiguration.ObjectLockEnabled == types.ObjectLockEnabledEnabled, nil
+}
@@ -0,0 +1,270 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+func TestParseRetention(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr error
+	}{
+		{
+			name:  "years",
+			value: "7y",
+			want:  7 * 365 * 24 * time.Hour,
+		},
+		{
+			name:  "days",
+			value: "30d",
+			want:  30 * 24 * time.Hour,
+		},
+		{
+			name:  "duration",
+			value: "12h",
+			want:  12 * time.Hour,
+		},
+		{
+			name:    "error on zero",
+			value:   "0d",
+			wantErr: ErrInvalidRetention,
+		},
+		{
+			name:    "error on invalid unit",
+			value:   "7w",
+			wantErr: ErrInvalidRetention,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseRetention(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestObjectLockMode_Set(t *testing.T) {
+	t.Parallel()
+
+	var mode ObjectLockMode
+
+	assert.NoError(t, mode.Set("compliance"))
+	assert.Equal(t, ObjectLockCompliance, mode)
+	assert.ErrorIs(t, mode.Set("invalid"), ErrInvalidObjectLockMode)
+}
+
+func TestS3_UploadObjectLock(t *testing.T) {
+	t.Parallel()
+
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		head    *s3.HeadObjectOutput
+		check   bool
+		wantErr error
+	}{
+		{
+			name: "overwrite expired retention",
+			head: &s3.HeadObjectOutput{
+				ETag:                      aws.String(`"0"`),
+				ObjectLockMode:            types.ObjectLockModeGovernance,
+				ObjectLockRetainUntilDate: &past,
+			},
+			check: true,
+		},
+		{
+			name: "refuse active retention",
+			head: &s3.HeadObjectOutput{
+				ETag:                      aws.String(`"0"`),
+				ObjectLockMode:            types.ObjectLockModeCompliance,
+				ObjectLockRetainUntilDate: &future,
+			},
+			check:   true,
+			wantErr: ErrObjectLocked,
+		},
+		{
+			name: "refuse legal hold",
+			head: &s3.HeadObjectOutput{
+				ETag:                      aws.String(`"0"`),
+				ObjectLockLegalHoldStatus: types.ObjectLockLegalHoldStatusOn,
+			},
+			check:   true,
+			wantErr: ErrObjectLocked,
+		},
+		{
+			name: "overwrite without lock checks",
+			head: &s3.HeadObjectOutput{
+				ETag:                      aws.String(`"0"`),
+				ObjectLockLegalHoldStatus: types.ObjectLockLegalHoldStatusOn,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			retainUntil := time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(tt.head, nil)
+
+			if tt.wantErr == nil {
+				mockS3Client.On("PutObject", mock.Anything, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+					return input.ObjectLockMode == types.ObjectLockModeCompliance &&
+						input.ObjectLockRetainUntilDate.Equal(retainUntil) &&
+						input.ObjectLockLegalHoldStatus == types.ObjectLockLegalHoldStatusOn
+				})).Return(&s3.PutObjectOutput{}, nil)
+			}
+
+			u := &S3{client: mockS3Client, Bucket: "test-bucket", CheckObjectLock: tt.check}
+
+			err := u.Upload(t.Context(), S3UploadOptions{
+				LocalFilePath:   createTempFile(t, "file.txt"),
+				RemoteObjectKey: "file.txt",
+				ObjectLock:      &ObjectLock{Mode: ObjectLockCompliance, RetainUntil: retainUntil, LegalHold: true},
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestS3_UploadMetadataObjectLock(t *testing.T) {
+	t.Parallel()
+
+	retainUntil := time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{
+		ChecksumCRC32C: aws.String("mnG7TA=="),
+		ContentType:    aws.String("application/octet-stream"),
+	}, nil)
+	mockS3Client.On("CopyObject", mock.Anything, mock.MatchedBy(func(input *s3.CopyObjectInput) bool {
+		return input.MetadataDirective == types.MetadataDirectiveReplace &&
+			input.ChecksumAlgorithm == types.ChecksumAlgorithmCrc32c &&
+			input.ObjectLockMode == types.ObjectLockModeGovernance &&
+			input.ObjectLockRetainUntilDate.Equal(retainUntil)
+	})).Return(&s3.CopyObjectOutput{}, nil)
+
+	u := &S3{client: mockS3Client, Bucket: "test-bucket", ChecksumAlgorithm: ChecksumAlgorithmCRC32C}
+
+	err := u.Upload(t.Context(), S3UploadOptions{
+		LocalFilePath:   createTempFile(t, "file.txt"),
+		RemoteObjectKey: "file.txt",
+		ContentType:     map[string]string{"*.txt": "text/plain"},
+		ObjectLock:      &ObjectLock{Mode: ObjectLockGovernance, RetainUntil: retainUntil},
+	})
+	assert.NoError(t, err)
+}
+
+func TestS3_DeleteObjectLock(t *testing.T) {
+	t.Parallel()
+
+	future := time.Now().Add(time.Hour)
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{
+		ObjectLockMode:            types.ObjectLockModeGovernance,
+		ObjectLockRetainUntilDate: &future,
+	}, nil)
+
+	// DeleteObject is not expected by the mock and must not be called.
+	u := &S3{client: mockS3Client, Bucket: "test-bucket", CheckObjectLock: true}
+
+	err := u.Delete(t.Context(), S3DeleteOptions{RemoteObjectKey: "file.txt"})
+	assert.ErrorIs(t, err, ErrObjectLocked)
+}
+
+func TestS3_DetectObjectLock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		resp    *s3.GetObjectLockConfigurationOutput
+		err     error
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "object lock enabled",
+			resp: &s3.GetObjectLockConfigurationOutput{
+				ObjectLockConfiguration: &types.ObjectLockConfiguration{ObjectLockEnabled: types.ObjectLockEnabledEnabled},
+			},
+			want: true,
+		},
+		{
+			name: "no object lock configuration",
+			resp: &s3.GetObjectLockConfigurationOutput{},
+			err:  &smithy.GenericAPIError{Code: "ObjectLockConfigurationNotFoundError"},
+			want: false,
+		},
+		{
+			name: "object lock not implemented",
+			resp: &s3.GetObjectLockConfigurationOutput{},
+			err:  &smithy.GenericAPIError{Code: "NotImplemented"},
+			want: true,
+		},
+		{
+			name: "access denied",
+			resp: &s3.GetObjectLockConfigurationOutput{},
+			err:  &smithy.GenericAPIError{Code: "AccessDenied"},
+			want: false,
+		},
+		{
+			name:    "error on internal error",
+			resp:    &s3.GetObjectLockConfigurationOutput{},
+			err:     &smithy.GenericAPIError{Code: "InternalError"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			mockS3Client.On("GetObjectLockConfiguration", mock.Anything, mock.Anything).Return(tt.resp, tt.err)
+
+			got, err := (&S3{client: mockS3Client, Bucket: "test-bucket"}).DetectObjectLock(t.Context())
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"context"
+	"maps"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Metadata keys that record the origin of an object.
+const (
+	MetadataCommit         = "ci-commit"
+	MetadataPipelineNumber = "ci-pipeline-number"
+	MetadataRepo           = "ci-repo"
+	MetadataDeployedAt     = "deployed-at"
+)
+
+// isProvenanceKey reports whether the metadata key is written for provenance. These keys
+// change with every pipeline and are ignored when detecting metadata drift.
+func isProvenanceKey(key string) bool {
+	switch key {
+	case MetadataCommit, MetadataPipelineNumber, MetadataRepo, MetadataDeployedAt:
+		return true
+	default:
+		return false
+	}
+}
+
+// withProvenance returns the metadata including the provenance metadata.
+func (u *S3) withProvenance(metadata map[string]string) map[string]string {
+	if len(u.Provenance) == 0 {
+		return metadata
+	}
+
+	merged := make(map[string]string, len(metadata)+len(u.Provenance))
+	maps.Copy(merged, metadata)
+	maps.Copy(merged, u.Provenance)
+
+	return merged
+}
+
+// withoutProvenance returns the metadata without provenance keys.
+func withoutProvenance(metadata map[string]string) map[string]string {
+	filtered := make(map[string]string, len(metadata))
+
+	for k, v := range metadata {
+		if !isProvenanceKey(k) {
+			filtered[k] = v
+		}
+	}
+
+	return filtered
+}
+
+// copyProvenance replaces the metadata of a copy with the metadata of its source and the provenance
+// metadata. All other headers of the source are kept, they are not copied with replaced metadata.
+func (u *S3) copyProvenance(ctx context.Context, input *s3.CopyObjectInput, opt S3CopyOptions) error {
+	if len(u.Provenance) == 0 {
+		return nil
+	}
+
+	headInput := &s3.HeadObjectInput{
+		Bucket: &u.Bucket,
+		Key:    &opt.SourceObjectKey,
+	}
+
+	if opt.SourceVersionID != "" {
+		headInput.VersionId = &opt.SourceVersionID
+	}
+
+	head, err := u.client.HeadObject(ctx, headInput)
+	if err != nil {
+		return err
+	}
+
+	input.MetadataDirective = types.MetadataDirectiveReplace
+	input.Metadata = u.withProvenance(head.Metadata)
+	input.ContentType = head.ContentType
+	input.ContentEncoding = head.ContentEncoding
+	input.CacheControl = head.CacheControl
+	input.ContentDisposition = head.ContentDisposition
+	input.ContentLanguage = head.ContentLanguage
+	input.Expires = head.Expires
+	input.WebsiteRedirectLocation = head.WebsiteRedirectLocation
+
+	return nil
+}
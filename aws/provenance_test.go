@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+func TestS3_WithProvenance(t *testing.T) {
+	t.Parallel()
+
+	u := &S3{Provenance: map[string]string{MetadataCommit: "abc", MetadataPipelineNumber: "42"}}
+	metadata := map[string]string{"owner": "team"}
+
+	got := u.withProvenance(metadata)
+
+	assert.Equal(t, map[string]string{"owner": "team", MetadataCommit: "abc", MetadataPipelineNumber: "42"}, got)
+	assert.Equal(t, map[string]string{"owner": "team"}, metadata)
+	assert.Equal(t, metadata, withoutProvenance(got))
+	assert.Equal(t, metadata, (&S3{}).withProvenance(metadata))
+}
+
+func TestS3_ShouldCopyObjectIgnoresProvenance(t *testing.T) {
+	t.Parallel()
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("GetObjectAcl", mock.Anything, mock.Anything).Return(&s3.GetObjectAclOutput{
+		Owner:  &types.Owner{ID: aws.String("owner")},
+		Grants: []types.Grant{{Grantee: &types.Grantee{ID: aws.String("owner")}, Permission: types.PermissionFullControl}},
+	}, nil)
+
+	u := &S3{
+		client:     mockS3Client,
+		Bucket:     "test-bucket",
+		Provenance: map[string]string{MetadataPipelineNumber: "43"},
+	}
+	head := &s3.HeadObjectOutput{
+		ContentType: aws.String("text/plain"),
+		Metadata: map[string]string{
+			"owner":                "team",
+			MetadataPipelineNumber: "42",
+			MetadataDeployedAt:     "2024-01-01T00:00:00Z",
+		},
+	}
+
+	shouldCopy, reason, err := u.shouldCopyObject(
+		t.Context(), head, "file.txt", "file.txt", "text/plain", "private", "", "", map[string]string{"owner": "team"},
+	)
+
+	assert.NoError(t, err)
+	assert.False(t, shouldCopy, reason)
+}
+
+func TestS3_CopyProvenance(t *testing.T) {
+	t.Parallel()
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("HeadObject", mock.Anything, mock.MatchedBy(func(input *s3.HeadObjectInput) bool {
+		return *input.Key == "v1/index.html" && aws.ToString(input.VersionId) == "version-1"
+	})).Return(&s3.HeadObjectOutput{
+		ContentType:  aws.String("text/html"),
+		CacheControl: aws.String("max-age=60"),
+		Metadata:     map[string]string{"owner": "team", MetadataCommit: "old"},
+	}, nil)
+	mockS3Client.On("CopyObject", mock.Anything, mock.MatchedBy(func(input *s3.CopyObjectInput) bool {
+		return input.MetadataDirective == types.MetadataDirectiveReplace &&
+			aws.ToString(input.ContentType) == "text/html" &&
+			aws.ToString(input.CacheControl) == "max-age=60" &&
+			assert.ObjectsAreEqual(map[string]string{"owner": "team", MetadataCommit: "abc"}, input.Metadata)
+	})).Return(&s3.CopyObjectOutput{}, nil)
+
+	u := &S3{client: mockS3Client, Bucket: "test-bucket", Provenance: map[string]string{MetadataCommit: "abc"}}
+
+	err := u.Copy(t.Context(), S3CopyOptions{
+		SourceObjectKey: "v1/index.html",
+		RemoteObjectKey: "latest/index.html",
+		SourceVersionID: "version-1",
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,68 @@
+package aws
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// RetryOptions configures how failed requests are retried.
+type RetryOptions struct {
+	MaxAttempts int
+	MaxBackoff  time.Duration
+	// OnThrottle is called for every failed attempt that was rejected with a throttling error.
+	OnThrottle func()
+}
+
+// IsThrottleError reports whether the error is a throttling response like `SlowDown` or an HTTP 503.
+func IsThrottleError(err error) bool {
+	var codeErr interface{ ErrorCode() string }
+	if errors.As(err, &codeErr) {
+		if _, ok := retry.DefaultThrottleErrorCodes[codeErr.ErrorCode()]; ok {
+			return true
+		}
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		switch statusErr.HTTPStatusCode() {
+		case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+			return true
+		}
+	}
+
+	return false
+}
+
+// newRetryer returns a standard retryer configured with the given options.
+func newRetryer(opt RetryOptions) aws.Retryer {
+	return retry.NewStandard(func(o *retry.StandardOptions) {
+		if opt.MaxAttempts > 0 {
+			o.MaxAttempts = opt.MaxAttempts
+		}
+
+		if opt.MaxBackoff > 0 {
+			o.MaxBackoff = opt.MaxBackoff
+		}
+
+		// Throttling is handled by adapting the concurrency, the client side retry quota
+		// would otherwise be drained within seconds by a large number of concurrent uploads.
+		o.RateLimiter = ratelimit.None
+
+		// The throttle check must not decide on retryability, it only observes the errors
+		// and is therefore placed in front of the default checks.
+		o.Retryables = append([]retry.IsErrorRetryable{
+			retry.IsErrorRetryableFunc(func(err error) aws.Ternary {
+				if opt.OnThrottle != nil && IsThrottleError(err) {
+					opt.OnThrottle()
+				}
+
+				return aws.UnknownTernary
+			}),
+		}, o.Retryables...)
+	})
+}
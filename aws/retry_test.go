@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+var ErrGeneric = errors.New("generic error")
+
+func TestIsThrottleError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "slow down error code",
+			err:  &smithy.GenericAPIError{Code: "SlowDown"},
+			want: true,
+		},
+		{
+			name: "service unavailable status",
+			err: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+				Err:      ErrGeneric,
+			},
+			want: true,
+		},
+		{
+			name: "not found status",
+			err: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusNotFound}},
+				Err:      ErrGeneric,
+			},
+			want: false,
+		},
+		{
+			name: "access denied error code",
+			err:  &smithy.GenericAPIError{Code: "AccessDenied"},
+			want: false,
+		},
+		{
+			name: "generic error",
+			err:  ErrGeneric,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, IsThrottleError(tt.err))
+		})
+	}
+}
+
+func TestNewRetryer(t *testing.T) {
+	t.Parallel()
+
+	throttled := 0
+	retryer := newRetryer(RetryOptions{
+		MaxAttempts: 7,
+		OnThrottle:  func() { throttled++ },
+	})
+
+	assert.Equal(t, 7, retryer.MaxAttempts())
+	assert.True(t, retryer.IsErrorRetryable(&smithy.GenericAPIError{Code: "SlowDown"}))
+	assert.False(t, retryer.IsErrorRetryable(&smithy.GenericAPIError{Code: "AccessDenied"}))
+	assert.Equal(t, 1, throttled)
+}
@@ -1,14 +1,13 @@
 package aws
 
 import (
+	"bytes"
 	"context"
-	"crypto/md5" //nolint:gosec
 	"errors"
 	"fmt"
-	"io"
-	"mime"
-	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -17,9 +16,32 @@ import (
 )
 
 type S3 struct {
-	client S3APIClient
-	Bucket string
-	DryRun bool
+	client    S3APIClient
+	Bucket    string
+	DryRun    bool
+	Bandwidth *BandwidthLimiter
+	PartSize  int64
+	Compare   CompareMode
+	// ChecksumAlgorithm is used for upload integrity and change detection instead of the MD5 based ETag.
+	ChecksumAlgorithm ChecksumAlgorithm
+	VerifyMode        VerifyMode
+	// Provenance is added to the metadata of all written objects but ignored for drift detection.
+	Provenance map[string]string
+	// MIMETypes maps file extensions to content types and takes precedence over the built-in table.
+	MIMETypes map[string]string
+	// DefaultCharset is added to text content types without an explicit charset.
+	DefaultCharset string
+	// ACLMode controls whether ACLs are sent, the zero value enables them.
+	ACLMode ACLMode
+	// ACLErrorPolicy controls how errors reading ACLs are handled, the zero value warns.
+	ACLErrorPolicy ACLErrorPolicy
+	// CheckObjectLock refuses to delete objects that are locked.
+	CheckObjectLock bool
+	// ConditionalWrites fails writes with ErrConflict if the object was changed since it was compared.
+	ConditionalWrites bool
+
+	ownerMu sync.Mutex
+	owner   *string
 }
 
 type S3UploadOptions struct {
@@ -30,6 +52,44 @@ type S3UploadOptions struct {
 	ContentEncoding map[string]string
 	CacheControl    map[string]string
 	Metadata        map[string]map[string]string
+	Multipart       MultipartStore
+
+	ContentDisposition map[string]string
+	ContentLanguage    map[string]string
+	Expires            map[string]string
+	WebsiteRedirect    map[string]string
+	// Headers are HTTP headers for this file that take precedence over the patterns.
+	Headers map[string]string
+	// ObjectLock is applied to the uploaded object, locked objects are never overwritten.
+	ObjectLock *ObjectLock
+	// SourceObjectKey is an object with identical content that is copied instead of uploading
+	// the file if the object does not exist yet.
+	SourceObjectKey string
+	// Open reads the content from somewhere else than the local file path, e.g. from an archive.
+	Open OpenFunc
+	// Written is called after the object was written. It is not called for unchanged objects and dry runs.
+	Written func()
+}
+
+// S3PutOptions describes generated content that is uploaded as is. The local file path
+// is only used to match the ACL patterns.
+type S3PutOptions struct {
+	LocalFilePath   string
+	RemoteObjectKey string
+	ACL             map[string]string
+	ContentType     string
+	Body            []byte
+}
+
+// S3CopyOptions describes a server-side copy within the bucket. The local file path is only
+// used to match the ACL patterns.
+type S3CopyOptions struct {
+	LocalFilePath   string
+	SourceObjectKey string
+	RemoteObjectKey string
+	ACL             map[string]string
+	// SourceVersionID copies a specific version of the source object instead of the current one.
+	SourceVersionID string
 }
 
 type S3RedirectOptions struct {
@@ -45,6 +105,13 @@ type S3ListOptions struct {
 	Path string
 }
 
+// S3Object is an object of a listing.
+type S3Object struct {
+	Key  string
+	ETag string
+	Size int64
+}
+
 // Upload uploads a file to an S3 bucket. It first checks if the file already exists in the bucket
 // and compares the local file's content and metadata with the remote file. If the file has changed,
 // it updates the remote file's metadata. If the file does not exist or has changed,
@@ -54,22 +121,38 @@ func (u *S3) Upload(ctx context.Context, opt S3UploadOptions) error {
 		return nil
 	}
 
-	file, err := os.Open(opt.LocalFilePath)
+	file, err := openLocal(opt.LocalFilePath, opt.Open)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	acl := getACL(opt.LocalFilePath, opt.ACL)
-	contentType := getContentType(opt.LocalFilePath, opt.ContentType)
-	contentEncoding := getContentEncoding(opt.LocalFilePath, opt.ContentEncoding)
-	cacheControl := getCacheControl(opt.LocalFilePath, opt.CacheControl)
-	metadata := getMetadata(opt.LocalFilePath, opt.Metadata)
+	fh, err := u.getFileHeaders(opt)
+	if err != nil {
+		return err
+	}
+
+	acl := fh.ACL
+	contentType := fh.ContentType
+	contentEncoding := fh.ContentEncoding
+	cacheControl := fh.CacheControl
+	metadata := fh.Metadata
+	headers := fh.objectHeaders
+
+	if err := u.compareMetadata(file, metadata); err != nil {
+		return err
+	}
 
-	head, err := u.client.HeadObject(ctx, &s3.HeadObjectInput{
+	headInput := &s3.HeadObjectInput{
 		Bucket: &u.Bucket,
 		Key:    &opt.RemoteObjectKey,
-	})
+	}
+
+	if u.ChecksumAlgorithm != "" {
+		headInput.ChecksumMode = types.ChecksumModeEnabled
+	}
+
+	head, err := u.client.HeadObject(ctx, headInput)
 	if err != nil {
 		var notFoundErr *types.NotFound
 		if !errors.As(err, &notFoundErr) {
@@ -87,54 +170,80 @@ func (u *S3) Upload(ctx context.Context, opt S3UploadOptions) error {
 			return nil
 		}
 
-		_, err = u.client.PutObject(ctx, &s3.PutObjectInput{
+		if opt.SourceObjectKey != "" {
+			copied, err := u.copyIdentical(ctx, opt, fh)
+			if copied || err != nil {
+				return opt.written(err)
+			}
+		}
+
+		input := &s3.PutObjectInput{
 			Bucket:          &u.Bucket,
 			Key:             &opt.RemoteObjectKey,
-			Body:            file,
 			ContentType:     &contentType,
-			ACL:             types.ObjectCannedACL(acl),
-			Metadata:        metadata,
+			ACL:             u.cannedACL(acl),
+			Metadata:        u.withProvenance(metadata),
 			CacheControl:    &cacheControl,
 			ContentEncoding: &contentEncoding,
-		})
+		}
+		headers.applyPut(input)
+		opt.ObjectLock.applyPut(input)
+		u.conditionPut(input, nil)
 
+		return opt.written(conflict(opt.RemoteObjectKey, u.put(ctx, file, input, opt.Multipart)))
+	}
+
+	changed, err := u.contentChanged(file, head)
+	if err != nil {
 		return err
 	}
 
-	//nolint:gosec
-	hash := md5.New()
-	_, _ = io.Copy(hash, file)
-	sum := fmt.Sprintf("'%x'", hash.Sum(nil))
+	if !changed {
+		shouldCopy, reason := headers.changed(head)
+		if !shouldCopy {
+			shouldCopy, reason, err = u.shouldCopyObject(
+				ctx, head, opt.LocalFilePath, opt.RemoteObjectKey, contentType, acl, contentEncoding, cacheControl, metadata,
+			)
+			if err != nil {
+				return err
+			}
+		}
 
-	if sum == *head.ETag {
-		shouldCopy, reason := u.shouldCopyObject(
-			ctx, head, opt.LocalFilePath, opt.RemoteObjectKey, contentType, acl, contentEncoding, cacheControl, metadata,
-		)
 		if !shouldCopy {
-			log.Debug().Msgf("skipping '%s' because hashes and metadata match", opt.LocalFilePath)
+			log.Debug().Msgf("skipping '%s' because content and metadata match", opt.LocalFilePath)
 
 			return nil
 		}
 
 		log.Debug().Msgf("updating metadata for '%s' %s", opt.LocalFilePath, reason)
 
+		if err := u.checkObjectLock(opt.RemoteObjectKey, head); err != nil {
+			return err
+		}
+
 		if u.DryRun {
 			return nil
 		}
 
-		_, err = u.client.CopyObject(ctx, &s3.CopyObjectInput{
+		input := &s3.CopyObjectInput{
 			Bucket:            &u.Bucket,
 			Key:               &opt.RemoteObjectKey,
 			CopySource:        aws.String(fmt.Sprintf("%s/%s", u.Bucket, opt.RemoteObjectKey)),
-			ACL:               types.ObjectCannedACL(acl),
+			ACL:               u.cannedACL(acl),
 			ContentType:       &contentType,
-			Metadata:          metadata,
+			Metadata:          u.withProvenance(metadata),
 			MetadataDirective: types.MetadataDirectiveReplace,
 			CacheControl:      &cacheControl,
 			ContentEncoding:   &contentEncoding,
-		})
+			ChecksumAlgorithm: u.ChecksumAlgorithm.sdk(),
+		}
+		headers.applyCopy(input)
+		opt.ObjectLock.applyCopy(input)
+		u.conditionCopy(input, head)
 
-		return err
+		_, err = u.client.CopyObject(ctx, input)
+
+		return opt.written(conflict(opt.RemoteObjectKey, err))
 	}
 
 	_, err = file.Seek(0, 0)
@@ -142,26 +251,80 @@ func (u *S3) Upload(ctx context.Context, opt S3UploadOptions) error {
 		return err
 	}
 
+	if err := u.checkObjectLock(opt.RemoteObjectKey, head); err != nil {
+		return err
+	}
+
 	log.Debug().Msgf("uploading '%s' with content-type '%s' and permissions '%s'", opt.LocalFilePath, contentType, acl)
 
 	if u.DryRun {
 		return nil
 	}
 
-	_, err = u.client.PutObject(ctx, &s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket:          &u.Bucket,
 		Key:             &opt.RemoteObjectKey,
-		Body:            file,
 		ContentType:     &contentType,
-		ACL:             types.ObjectCannedACL(acl),
-		Metadata:        metadata,
+		ACL:             u.cannedACL(acl),
+		Metadata:        u.withProvenance(metadata),
 		CacheControl:    &cacheControl,
 		ContentEncoding: &contentEncoding,
-	})
+	}
+	headers.applyPut(input)
+	opt.ObjectLock.applyPut(input)
+	u.conditionPut(input, head)
+
+	return opt.written(conflict(opt.RemoteObjectKey, u.put(ctx, file, input, opt.Multipart)))
+}
+
+// written calls the Written callback if the object was written without error.
+func (opt S3UploadOptions) written(err error) error {
+	if err == nil && opt.Written != nil {
+		opt.Written()
+	}
 
 	return err
 }
 
+// MaxCopySize is the size of the largest object that can be copied with a single request.
+const MaxCopySize = 5 * 1024 * 1024 * 1024
+
+// copyIdentical copies the source object with identical content to the remote key and replaces
+// its headers. False is returned if the copy failed for another reason than a conflict, the file
+// is uploaded instead in that case.
+func (u *S3) copyIdentical(ctx context.Context, opt S3UploadOptions, fh fileHeaders) (bool, error) {
+	log.Debug().Msgf("copying '%s' from '%s' with identical content", opt.RemoteObjectKey, opt.SourceObjectKey)
+
+	input := &s3.CopyObjectInput{
+		Bucket:            &u.Bucket,
+		Key:               &opt.RemoteObjectKey,
+		CopySource:        aws.String(fmt.Sprintf("%s/%s", u.Bucket, opt.SourceObjectKey)),
+		ACL:               u.cannedACL(fh.ACL),
+		ContentType:       &fh.ContentType,
+		Metadata:          u.withProvenance(fh.Metadata),
+		MetadataDirective: types.MetadataDirectiveReplace,
+		CacheControl:      &fh.CacheControl,
+		ContentEncoding:   &fh.ContentEncoding,
+		ChecksumAlgorithm: u.ChecksumAlgorithm.sdk(),
+	}
+	fh.applyCopy(input)
+	opt.ObjectLock.applyCopy(input)
+	u.conditionCopyTarget(input, nil)
+
+	_, err := u.client.CopyObject(ctx, input)
+	if err != nil {
+		if conditionFailed(err) {
+			return false, conflict(opt.RemoteObjectKey, err)
+		}
+
+		log.Debug().Msgf("failed to copy '%s', uploading '%s': %v", opt.SourceObjectKey, opt.LocalFilePath, err)
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
 // shouldCopyObject determines whether an S3 object should be copied based on changes in content type,
 // content encoding, cache control, and metadata. It compares the existing object's metadata with the
 // provided metadata and returns a boolean indicating whether the object should be copied,
@@ -172,99 +335,70 @@ func (u *S3) shouldCopyObject(
 	ctx context.Context, head *s3.HeadObjectOutput,
 	local, remote, contentType, acl, contentEncoding, cacheControl string,
 	metadata map[string]string,
-) (bool, string) {
+) (bool, string, error) {
 	var reason string
 
+	headMetadata := withoutProvenance(head.Metadata)
+
 	if head.ContentType == nil && contentType != "" {
 		reason = fmt.Sprintf("content-type has changed from unset to %s", contentType)
 
-		return true, reason
+		return true, reason, nil
 	}
 
 	if head.ContentType != nil && contentType != *head.ContentType {
 		reason = fmt.Sprintf("content-type has changed from %s to %s", *head.ContentType, contentType)
 
-		return true, reason
+		return true, reason, nil
 	}
 
 	if head.ContentEncoding == nil && contentEncoding != "" {
 		reason = fmt.Sprintf("Content-Encoding has changed from unset to %s", contentEncoding)
 
-		return true, reason
+		return true, reason, nil
 	}
 
 	if head.ContentEncoding != nil && contentEncoding != *head.ContentEncoding {
 		reason = fmt.Sprintf("Content-Encoding has changed from %s to %s", *head.ContentEncoding, contentEncoding)
 
-		return true, reason
+		return true, reason, nil
 	}
 
 	if head.CacheControl == nil && cacheControl != "" {
 		reason = fmt.Sprintf("cache-control has changed from unset to %s", cacheControl)
 
-		return true, reason
+		return true, reason, nil
 	}
 
 	if head.CacheControl != nil && cacheControl != *head.CacheControl {
 		reason = fmt.Sprintf("cache-control has changed from %s to %s", *head.CacheControl, cacheControl)
 
-		return true, reason
+		return true, reason, nil
 	}
 
-	if len(head.Metadata) != len(metadata) {
+	if len(headMetadata) != len(metadata) {
 		reason = fmt.Sprintf("count of metadata values has changed for %s", local)
 
-		return true, reason
+		return true, reason, nil
 	}
 
 	if len(metadata) > 0 {
 		for k, v := range metadata {
-			if hv, ok := head.Metadata[k]; ok {
+			if hv, ok := headMetadata[k]; ok {
 				if v != hv {
 					reason = fmt.Sprintf("metadata values have changed for %s", remote)
 
-					return true, reason
+					return true, reason, nil
 				}
 			}
 		}
 	}
 
-	grant, err := u.client.GetObjectAcl(ctx, &s3.GetObjectAclInput{
-		Bucket: &u.Bucket,
-		Key:    &remote,
-	})
-	if err != nil {
-		return false, ""
-	}
-
-	previousACL := "private"
-
-	for _, g := range grant.Grants {
-		grantee := g.Grantee
-		if grantee.URI != nil {
-			switch *grantee.URI {
-			case "http://acs.amazonaws.com/groups/global/AllUsers":
-				//nolint:staticcheck
-				if g.Permission == "READ" {
-					previousACL = "public-read"
-				} else if g.Permission == "WRITE" {
-					previousACL = "public-read-write"
-				}
-			case "http://acs.amazonaws.com/groups/global/AuthenticatedUsers":
-				if g.Permission == "READ" {
-					previousACL = "authenticated-read"
-				}
-			}
-		}
-	}
-
-	if previousACL != acl {
-		reason = fmt.Sprintf("permissions for '%s' have changed from '%s' to '%s'", remote, previousACL, acl)
-
-		return true, reason
+	if !u.aclEnabled() {
+		return false, "", nil
 	}
 
-	return false, ""
+	return u.aclChanged(ctx, remote, acl)
 }
 
 // getACL returns the ACL for the given file based on the provided patterns.
@@ -279,13 +413,14 @@ func getACL(file string, patterns map[string]string) string {
 }
 
 // getContentType returns the content type for the given file based on the provided patterns.
-func getContentType(file string, patterns map[string]string) string {
+// Without a matching pattern the type is looked up in the MIME tables or detected from the content.
+func (u *S3) getContentType(file string, open OpenFunc, patterns map[string]string) string {
 	ext := filepath.Ext(file)
 	if contentType, ok := patterns[ext]; ok {
 		return contentType
 	}
 
-	return mime.TypeByExtension(ext)
+	return u.withCharset(u.detectContentType(file, open, ext))
 }
 
 // getContentEncoding returns the content encoding for the given file based on the provided patterns.
@@ -334,36 +469,165 @@ func (u *S3) Redirect(ctx context.Context, opt S3RedirectOptions) error {
 		return nil
 	}
 
-	_, err := u.client.PutObject(ctx, &s3.PutObjectInput{
+	head, err := u.conditionHead(ctx, opt.Path)
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
 		Bucket:                  aws.String(u.Bucket),
 		Key:                     aws.String(opt.Path),
-		ACL:                     types.ObjectCannedACLPublicRead,
+		ACL:                     u.cannedACL(string(types.ObjectCannedACLPublicRead)),
 		WebsiteRedirectLocation: aws.String(opt.Location),
-	})
+		Metadata:                u.withProvenance(nil),
+	}
+	u.conditionPut(input, head)
 
-	return err
+	_, err = u.client.PutObject(ctx, input)
+
+	return conflict(opt.Path, err)
+}
+
+// Put uploads generated content to the S3 bucket.
+func (u *S3) Put(ctx context.Context, opt S3PutOptions) error {
+	acl := getACL(opt.LocalFilePath, opt.ACL)
+
+	log.Debug().Msgf("writing '%s' with content-type '%s' and permissions '%s'", opt.RemoteObjectKey, opt.ContentType, acl)
+
+	if u.DryRun {
+		return nil
+	}
+
+	head, err := u.conditionHead(ctx, opt.RemoteObjectKey)
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:            aws.String(u.Bucket),
+		Key:               aws.String(opt.RemoteObjectKey),
+		ACL:               u.cannedACL(acl),
+		ContentType:       aws.String(opt.ContentType),
+		Metadata:          u.withProvenance(nil),
+		Body:              bytes.NewReader(opt.Body),
+		ChecksumAlgorithm: u.ChecksumAlgorithm.sdk(),
+	}
+	u.conditionPut(input, head)
+
+	_, err = u.client.PutObject(ctx, input)
+
+	return conflict(opt.RemoteObjectKey, err)
+}
+
+// Copy copies an object within the S3 bucket. Content type and metadata are kept from the source object,
+// the provenance metadata is replaced.
+func (u *S3) Copy(ctx context.Context, opt S3CopyOptions) error {
+	acl := getACL(opt.LocalFilePath, opt.ACL)
+	source := fmt.Sprintf("%s/%s", u.Bucket, opt.SourceObjectKey)
+
+	if opt.SourceVersionID != "" {
+		source += "?versionId=" + opt.SourceVersionID
+	}
+
+	log.Debug().Msgf("copying '%s' to '%s' with permissions '%s'", source, opt.RemoteObjectKey, acl)
+
+	if u.DryRun {
+		return nil
+	}
+
+	head, err := u.conditionHead(ctx, opt.RemoteObjectKey)
+	if err != nil {
+		return err
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(u.Bucket),
+		Key:               aws.String(opt.RemoteObjectKey),
+		CopySource:        aws.String(source),
+		ACL:               u.cannedACL(acl),
+		MetadataDirective: types.MetadataDirectiveCopy,
+	}
+	u.conditionCopyTarget(input, head)
+
+	if err := u.copyProvenance(ctx, input, opt); err != nil {
+		return err
+	}
+
+	_, err = u.client.CopyObject(ctx, input)
+
+	return conflict(opt.RemoteObjectKey, err)
 }
 
 // Delete removes the specified object from the S3 bucket.
 func (u *S3) Delete(ctx context.Context, opt S3DeleteOptions) error {
 	log.Debug().Msgf("removing remote file '%s'", opt.RemoteObjectKey)
 
+	input := &s3.DeleteObjectInput{
+		Bucket: aws.String(u.Bucket),
+		Key:    aws.String(opt.RemoteObjectKey),
+	}
+
+	if u.CheckObjectLock || u.ConditionalWrites {
+		head, err := u.client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(u.Bucket),
+			Key:    aws.String(opt.RemoteObjectKey),
+		})
+		if err != nil {
+			// The object was already deleted.
+			var notFoundErr *types.NotFound
+			if errors.As(err, &notFoundErr) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := u.checkObjectLock(opt.RemoteObjectKey, head); err != nil {
+			return err
+		}
+
+		// Objects modified since they were compared are not deleted.
+		if u.ConditionalWrites {
+			input.IfMatch = head.ETag
+		}
+	}
+
 	if u.DryRun {
 		return nil
 	}
 
-	_, err := u.client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(u.Bucket),
-		Key:    aws.String(opt.RemoteObjectKey),
-	})
+	_, err := u.client.DeleteObject(ctx, input)
 
-	return err
+	return conflict(opt.RemoteObjectKey, err)
 }
 
 // List retrieves a list of object keys in the S3 bucket under the specified path.
 func (u *S3) List(ctx context.Context, opt S3ListOptions) ([]string, error) {
 	var remote []string
 
+	err := u.listObjects(ctx, opt, func(item types.Object) {
+		remote = append(remote, *item.Key)
+	})
+
+	return remote, err
+}
+
+// ListObjects returns the keys, ETags and sizes of all objects in the S3 bucket under the specified path.
+func (u *S3) ListObjects(ctx context.Context, opt S3ListOptions) ([]S3Object, error) {
+	var objects []S3Object
+
+	err := u.listObjects(ctx, opt, func(item types.Object) {
+		objects = append(objects, S3Object{
+			Key:  *item.Key,
+			ETag: strings.Trim(aws.ToString(item.ETag), `"'`),
+			Size: aws.ToInt64(item.Size),
+		})
+	})
+
+	return objects, err
+}
+
+func (u *S3) listObjects(ctx context.Context, opt S3ListOptions, fn func(item types.Object)) error {
 	input := &s3.ListObjectsInput{
 		Bucket: aws.String(u.Bucket),
 		Prefix: aws.String(opt.Path),
@@ -372,19 +636,19 @@ func (u *S3) List(ctx context.Context, opt S3ListOptions) ([]string, error) {
 	for {
 		resp, err := u.client.ListObjects(ctx, input)
 		if err != nil {
-			return remote, err
+			return err
 		}
 
 		for _, item := range resp.Contents {
-			remote = append(remote, *item.Key)
+			fn(item)
 		}
 
-		if !*resp.IsTruncated {
+		if !*resp.IsTruncated || len(resp.Contents) == 0 {
 			break
 		}
 
-		input.Marker = aws.String(remote[len(remote)-1])
+		input.Marker = resp.Contents[len(resp.Contents)-1].Key
 	}
 
-	return remote, nil
+	return nil
 }
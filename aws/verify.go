@@ -0,0 +1,150 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/rs/zerolog/log"
+)
+
+type VerifyMode string
+
+const (
+	// VerifyChecksum compares the stored checksum or ETag of the object with the local file.
+	VerifyChecksum VerifyMode = "checksum"
+	// VerifyDownload downloads the object and compares its hash with the local file.
+	VerifyDownload VerifyMode = "download"
+)
+
+var (
+	ErrInvalidVerifyMode = errors.New("invalid verify mode")
+	ErrVerifyMismatch    = errors.New("object does not match local file")
+)
+
+type S3VerifyOptions struct {
+	LocalFilePath   string
+	RemoteObjectKey string
+	// Open reads the content from somewhere else than the local file path, e.g. from an archive.
+	Open OpenFunc
+}
+
+// Set validates the mode. An empty value disables the verification.
+func (vm *VerifyMode) Set(value string) error {
+	switch VerifyMode(value) {
+	case "", VerifyChecksum, VerifyDownload:
+		*vm = VerifyMode(value)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidVerifyMode, value)
+	}
+}
+
+func (vm *VerifyMode) String() string {
+	return string(*vm)
+}
+
+// Verify ensures the stored object matches the local file according to the verify mode.
+// A mismatch returns ErrVerifyMismatch.
+func (u *S3) Verify(ctx context.Context, opt S3VerifyOptions) error {
+	if u.VerifyMode == "" || u.DryRun {
+		return nil
+	}
+
+	log.Debug().Msgf("verifying '%s' using mode '%s'", opt.RemoteObjectKey, u.VerifyMode)
+
+	file, err := openLocal(opt.LocalFilePath, opt.Open)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var local, remote string
+
+	switch u.VerifyMode {
+	case VerifyDownload:
+		local, remote, err = u.verifyDownload(ctx, file, opt.RemoteObjectKey)
+	default:
+		local, remote, err = u.verifyChecksum(ctx, file, opt.RemoteObjectKey)
+
+		// Objects uploaded in parts get a plain ETag if they are copied, e.g. when only the headers
+		// changed, and copies of small objects may be made in parts. The content is compared instead.
+		if err == nil && u.ChecksumAlgorithm == "" && multipartETag(local) != multipartETag(remote) {
+			log.Debug().Msgf("ETag of '%s' is not comparable, verifying by download", opt.RemoteObjectKey)
+
+			if _, err = file.Seek(0, io.SeekStart); err == nil {
+				local, remote, err = u.verifyDownload(ctx, file, opt.RemoteObjectKey)
+			}
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if local != remote {
+		return fmt.Errorf("%w: '%s' expected '%s' but got '%s'", ErrVerifyMismatch, opt.RemoteObjectKey, local, remote)
+	}
+
+	return nil
+}
+
+// verifyChecksum returns the checksum of the local file and the checksum stored for the object.
+func (u *S3) verifyChecksum(ctx context.Context, file LocalFile, key string) (string, string, error) {
+	input := &s3.HeadObjectInput{
+		Bucket: &u.Bucket,
+		Key:    &key,
+	}
+
+	if u.ChecksumAlgorithm != "" {
+		input.ChecksumMode = types.ChecksumModeEnabled
+	}
+
+	head, err := u.client.HeadObject(ctx, input)
+	if err != nil {
+		return "", "", err
+	}
+
+	local, err := u.fileChecksum(file)
+	if err != nil {
+		return "", "", err
+	}
+
+	return local, u.remoteChecksum(head), nil
+}
+
+// multipartETag reports whether the ETag is the one of an object uploaded in parts.
+func multipartETag(etag string) bool {
+	return strings.Contains(etag, "-")
+}
+
+// verifyDownload returns the SHA-256 hash of the local file and of the downloaded object.
+func (u *S3) verifyDownload(ctx context.Context, file LocalFile, key string) (string, string, error) {
+	resp, err := u.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &u.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	remote := sha256.New()
+	if _, err := io.Copy(remote, u.limitBody(ctx, resp.Body)); err != nil {
+		return "", "", fmt.Errorf("failed to download '%s': %w", key, err)
+	}
+
+	local := sha256.New()
+	if _, err := io.Copy(local, file); err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(local.Sum(nil)), hex.EncodeToString(remote.Sum(nil)), nil
+}
@@ -0,0 +1,243 @@
+package aws
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+func TestVerifyMode_Set(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    VerifyMode
+		wantErr error
+	}{
+		{
+			name:  "set empty mode",
+			value: "",
+			want:  "",
+		},
+		{
+			name:  "set checksum mode",
+			value: "checksum",
+			want:  VerifyChecksum,
+		},
+		{
+			name:  "set download mode",
+			value: "download",
+			want:  VerifyDownload,
+		},
+		{
+			name:    "error on invalid mode",
+			value:   "invalid",
+			wantErr: ErrInvalidVerifyMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mode VerifyMode
+
+			err := mode.Set(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, mode)
+			assert.Equal(t, tt.value, mode.String())
+		})
+	}
+}
+
+func TestS3_Verify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T) *S3
+		wantErr error
+	}{
+		{
+			name: "skip when verification is disabled",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				return &S3{client: mocks.NewMockS3APIClient(t), Bucket: "test-bucket"}
+			},
+		},
+		{
+			name: "matching etag",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{
+					ETag: aws.String(`"5d41402abc4b2a76b9719d911017c592"`),
+				}, nil)
+
+				return &S3{client: mockS3Client, Bucket: "test-bucket", VerifyMode: VerifyChecksum}
+			},
+		},
+		{
+			name: "download if etag of copy is not comparable",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{
+					ETag: aws.String(`"9ad2b4e1e7bbd4e7c3c5d1ab3b1c53ab-2"`),
+				}, nil)
+				mockS3Client.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{
+					Body: io.NopCloser(strings.NewReader("hello")),
+				}, nil)
+
+				return &S3{client: mockS3Client, Bucket: "test-bucket", VerifyMode: VerifyChecksum}
+			},
+		},
+		{
+			name: "error on mismatching checksum",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{
+					ChecksumCRC32C: aws.String("AAAAAA=="),
+				}, nil)
+
+				return &S3{
+					client:            mockS3Client,
+					Bucket:            "test-bucket",
+					VerifyMode:        VerifyChecksum,
+					ChecksumAlgorithm: ChecksumAlgorithmCRC32C,
+				}
+			},
+			wantErr: ErrVerifyMismatch,
+		},
+		{
+			name: "matching download",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{
+					Body: io.NopCloser(strings.NewReader("hello")),
+				}, nil)
+
+				return &S3{client: mockS3Client, Bucket: "test-bucket", VerifyMode: VerifyDownload}
+			},
+		},
+		{
+			name: "error on mismatching download",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{
+					Body: io.NopCloser(strings.NewReader("hellO")),
+				}, nil)
+
+				return &S3{client: mockS3Client, Bucket: "test-bucket", VerifyMode: VerifyDownload}
+			},
+			wantErr: ErrVerifyMismatch,
+		},
+		{
+			name: "error on failed download",
+			setup: func(t *testing.T) *S3 {
+				t.Helper()
+
+				mockS3Client := mocks.NewMockS3APIClient(t)
+				mockS3Client.On("GetObject", mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{}, ErrGetObject)
+
+				return &S3{client: mockS3Client, Bucket: "test-bucket", VerifyMode: VerifyDownload}
+			},
+			wantErr: ErrGetObject,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.setup(t).Verify(t.Context(), S3VerifyOptions{
+				LocalFilePath:   createTempFile(t, "file.txt"),
+				RemoteObjectKey: "file.txt",
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestS3_UploadWritten(t *testing.T) {
+	t.Parallel()
+
+	contentType := "text/plain; charset=utf-8"
+
+	tests := []struct {
+		name string
+		head *s3.HeadObjectOutput
+		want bool
+	}{
+		{
+			name: "write changed object",
+			head: &s3.HeadObjectOutput{ChecksumCRC32C: aws.String("AAAAAA=="), ContentType: aws.String(contentType)},
+			want: true,
+		},
+		{
+			name: "skip unchanged object",
+			head: &s3.HeadObjectOutput{ChecksumCRC32C: aws.String("mnG7TA=="), ContentType: aws.String(contentType)},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			mockS3Client.On("HeadObject", mock.Anything, mock.Anything).Return(tt.head, nil)
+
+			if tt.want {
+				mockS3Client.On("PutObject", mock.Anything, mock.Anything).Return(&s3.PutObjectOutput{}, nil)
+			}
+
+			u := &S3{
+				client:            mockS3Client,
+				Bucket:            "test-bucket",
+				ChecksumAlgorithm: ChecksumAlgorithmCRC32C,
+				ACLMode:           ACLModeDisabled,
+			}
+
+			var written bool
+
+			err := u.Upload(t.Context(), S3UploadOptions{
+				LocalFilePath:   createTempFile(t, "file.txt"),
+				RemoteObjectKey: "file.txt",
+				ContentType:     map[string]string{"*.txt": "text/plain"},
+				Written:         func() { written = true },
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, written)
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/rs/zerolog/log"
+)
+
+// maxDeleteObjects is the largest number of versions S3 deletes with a single request.
+const maxDeleteObjects = 1000
+
+var ErrDeleteVersions = errors.New("failed to delete versions")
+
+type S3PruneOptions struct {
+	Path string
+	// Keys are the objects whose noncurrent versions are pruned.
+	Keys map[string]bool
+	// KeepVersions is the number of noncurrent versions kept, a negative value keeps all versions.
+	KeepVersions int
+	// PurgeDeleteMarkers removes delete markers below the path that no longer hide a version.
+	PurgeDeleteMarkers bool
+}
+
+// objectVersion is a version or delete marker of an object.
+type objectVersion struct {
+	id           string
+	latest       bool
+	deleteMarker bool
+	lastModified int64
+}
+
+// PruneVersions deletes noncurrent versions of the given keys beyond the number of kept versions and
+// purges delete markers. A current delete marker is only purged if no version remains below it, which
+// would otherwise restore the deleted object.
+func (u *S3) PruneVersions(ctx context.Context, opt S3PruneOptions) error {
+	versions, err := u.listVersions(ctx, opt.Path)
+	if err != nil {
+		return err
+	}
+
+	var prune []types.ObjectIdentifier
+
+	keys := make([]string, 0, len(versions))
+	for key := range versions {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		pruned, kept := len(prune), 0
+
+		for _, v := range versions[key] {
+			if v.deleteMarker || v.latest {
+				continue
+			}
+
+			if opt.Keys[key] && opt.KeepVersions >= 0 && kept >= opt.KeepVersions {
+				prune = append(prune, types.ObjectIdentifier{Key: aws.String(key), VersionId: aws.String(v.id)})
+
+				continue
+			}
+
+			kept++
+		}
+
+		if !opt.PurgeDeleteMarkers {
+			continue
+		}
+
+		for _, v := range versions[key] {
+			if !v.deleteMarker || (v.latest && hasVersion(versions[key], prune[pruned:])) {
+				continue
+			}
+
+			prune = append(prune, types.ObjectIdentifier{Key: aws.String(key), VersionId: aws.String(v.id)})
+		}
+	}
+
+	return u.deleteVersions(ctx, prune)
+}
+
+// hasVersion reports whether an object version is left after the pruned versions are deleted.
+func hasVersion(versions []objectVersion, prune []types.ObjectIdentifier) bool {
+	pruned := make(map[string]bool, len(prune))
+	for _, p := range prune {
+		pruned[aws.ToString(p.VersionId)] = true
+	}
+
+	for _, v := range versions {
+		if !v.deleteMarker && !pruned[v.id] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// listVersions returns the versions and delete markers of all objects below the path,
+// ordered from the newest to the oldest.
+func (u *S3) listVersions(ctx context.Context, path string) (map[string][]objectVersion, error) {
+	versions := make(map[string][]objectVersion)
+
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(u.Bucket),
+		Prefix: aws.String(path),
+	}
+
+	for {
+		resp, err := u.client.ListObjectVersions(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range resp.Versions {
+			key := aws.ToString(v.Key)
+			versions[key] = append(versions[key], objectVersion{
+				id:           aws.ToString(v.VersionId),
+				latest:       aws.ToBool(v.IsLatest),
+				lastModified: aws.ToTime(v.LastModified).UnixNano(),
+			})
+		}
+
+		for _, m := range resp.DeleteMarkers {
+			key := aws.ToString(m.Key)
+			versions[key] = append(versions[key], objectVersion{
+				id:           aws.ToString(m.VersionId),
+				latest:       aws.ToBool(m.IsLatest),
+				deleteMarker: true,
+				lastModified: aws.ToTime(m.LastModified).UnixNano(),
+			})
+		}
+
+		if !aws.ToBool(resp.IsTruncated) {
+			break
+		}
+
+		input.KeyMarker = resp.NextKeyMarker
+		input.VersionIdMarker = resp.NextVersionIdMarker
+	}
+
+	for _, v := range versions {
+		sort.SliceStable(v, func(i, j int) bool {
+			if v[i].latest != v[j].latest {
+				return v[i].latest
+			}
+
+			return v[i].lastModified > v[j].lastModified
+		})
+	}
+
+	return versions, nil
+}
+
+// deleteVersions deletes the versions in batches. Versions that cannot be deleted, e.g. because
+// they are locked, are reported after all batches were sent.
+func (u *S3) deleteVersions(ctx context.Context, versions []types.ObjectIdentifier) error {
+	for _, v := range versions {
+		log.Debug().Msgf("removing version '%s' of '%s'", aws.ToString(v.VersionId), aws.ToString(v.Key))
+	}
+
+	if u.DryRun || len(versions) == 0 {
+		return nil
+	}
+
+	var errs []error
+
+	for start := 0; start < len(versions); start += maxDeleteObjects {
+		resp, err := u.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(u.Bucket),
+			Delete: &types.Delete{
+				Objects: versions[start:min(start+maxDeleteObjects, len(versions))],
+				Quiet:   aws.Bool(true),
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, e := range resp.Errors {
+			errs = append(errs, fmt.Errorf(
+				"%w: '%s' version '%s': %s", ErrDeleteVersions,
+				aws.ToString(e.Key), aws.ToString(e.VersionId), aws.ToString(e.Message),
+			))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RestorePlan describes the changes that restore a prefix to an earlier point in time.
+type RestorePlan struct {
+	// Versions maps keys to the version that was current at the time.
+	Versions map[string]string
+	// Deleted are the keys that did not exist at the time.
+	Deleted []string
+}
+
+// PlanRestore computes the version of each key below the path that was current at the given time.
+// Keys whose current version already matches are left out of the plan.
+func (u *S3) PlanRestore(ctx context.Context, path string, at time.Time) (*RestorePlan, error) {
+	versions, err := u.listVersions(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &RestorePlan{Versions: make(map[string]string)}
+
+	for key, vs := range versions {
+		var current, past *objectVersion
+
+		for i := range vs {
+			if vs[i].latest {
+				current = &vs[i]
+			}
+
+			if past == nil && vs[i].lastModified <= at.UnixNano() {
+				past = &vs[i]
+			}
+		}
+
+		exists := current != nil && !current.deleteMarker
+
+		switch {
+		case past == nil || past.deleteMarker:
+			if exists {
+				plan.Deleted = append(plan.Deleted, key)
+			}
+		case !exists || current.id != past.id:
+			plan.Versions[key] = past.id
+		}
+	}
+
+	sort.Strings(plan.Deleted)
+
+	return plan, nil
+}
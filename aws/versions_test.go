@@ -0,0 +1,178 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thegeeklab/wp-s3-action/aws/mocks"
+)
+
+func TestS3_PruneVersions(t *testing.T) {
+	t.Parallel()
+
+	at := func(hour int) *time.Time {
+		ts := time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)
+
+		return &ts
+	}
+	version := func(key, id string, hour int, latest bool) types.ObjectVersion {
+		return types.ObjectVersion{Key: aws.String(key), VersionId: aws.String(id), LastModified: at(hour), IsLatest: &latest}
+	}
+	marker := func(key, id string, hour int, latest bool) types.DeleteMarkerEntry {
+		return types.DeleteMarkerEntry{
+			Key: aws.String(key), VersionId: aws.String(id), LastModified: at(hour), IsLatest: &latest,
+		}
+	}
+
+	listing := &s3.ListObjectVersionsOutput{
+		Versions: []types.ObjectVersion{
+			version("site/index.html", "i3", 3, true),
+			version("site/index.html", "i1", 1, false),
+			version("site/index.html", "i2", 2, false),
+			version("site/other.html", "o2", 2, true),
+			version("site/other.html", "o1", 1, false),
+			version("site/removed.html", "r1", 1, false),
+		},
+		DeleteMarkers: []types.DeleteMarkerEntry{
+			marker("site/removed.html", "rm", 2, true),
+			marker("site/gone.html", "gm", 2, true),
+			marker("site/other.html", "om", 1, false),
+		},
+	}
+
+	tests := []struct {
+		name string
+		opt  S3PruneOptions
+		want []string
+	}{
+		{
+			name: "keep newest noncurrent version of touched keys",
+			opt: S3PruneOptions{
+				Keys:         map[string]bool{"site/index.html": true},
+				KeepVersions: 1,
+			},
+			want: []string{"site/index.html@i1"},
+		},
+		{
+			name: "prune all noncurrent versions",
+			opt: S3PruneOptions{
+				Keys:         map[string]bool{"site/index.html": true, "site/removed.html": true},
+				KeepVersions: 0,
+			},
+			want: []string{"site/index.html@i2", "site/index.html@i1", "site/removed.html@r1"},
+		},
+		{
+			name: "purge delete markers that hide no version",
+			opt: S3PruneOptions{
+				KeepVersions:       -1,
+				PurgeDeleteMarkers: true,
+			},
+			want: []string{"site/gone.html@gm", "site/other.html@om"},
+		},
+		{
+			name: "purge delete marker of pruned key",
+			opt: S3PruneOptions{
+				Keys:               map[string]bool{"site/removed.html": true},
+				KeepVersions:       0,
+				PurgeDeleteMarkers: true,
+			},
+			want: []string{"site/gone.html@gm", "site/other.html@om", "site/removed.html@r1", "site/removed.html@rm"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+
+			mockS3Client := mocks.NewMockS3APIClient(t)
+			mockS3Client.On("ListObjectVersions", mock.Anything, mock.Anything).Return(listing, nil)
+			mockS3Client.On("DeleteObjects", mock.Anything, mock.MatchedBy(func(input *s3.DeleteObjectsInput) bool {
+				for _, obj := range input.Delete.Objects {
+					got = append(got, aws.ToString(obj.Key)+"@"+aws.ToString(obj.VersionId))
+				}
+
+				return true
+			})).Return(&s3.DeleteObjectsOutput{}, nil)
+
+			u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+
+			tt.opt.Path = "site"
+
+			assert.NoError(t, u.PruneVersions(t.Context(), tt.opt))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestS3_PruneVersionsError(t *testing.T) {
+	t.Parallel()
+
+	latest := false
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("ListObjectVersions", mock.Anything, mock.Anything).Return(&s3.ListObjectVersionsOutput{
+		Versions: []types.ObjectVersion{
+			{Key: aws.String("file.txt"), VersionId: aws.String("v1"), IsLatest: &latest},
+		},
+	}, nil)
+	mockS3Client.On("DeleteObjects", mock.Anything, mock.Anything).Return(&s3.DeleteObjectsOutput{
+		Errors: []types.Error{
+			{Key: aws.String("file.txt"), VersionId: aws.String("v1"), Message: aws.String("Access Denied")},
+		},
+	}, nil)
+
+	u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+
+	err := u.PruneVersions(t.Context(), S3PruneOptions{Keys: map[string]bool{"file.txt": true}})
+	assert.ErrorIs(t, err, ErrDeleteVersions)
+}
+
+func TestS3_PlanRestore(t *testing.T) {
+	t.Parallel()
+
+	at := func(hour int) *time.Time {
+		ts := time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)
+
+		return &ts
+	}
+	version := func(key, id string, hour int, latest bool) types.ObjectVersion {
+		return types.ObjectVersion{Key: aws.String(key), VersionId: aws.String(id), LastModified: at(hour), IsLatest: &latest}
+	}
+	marker := func(key, id string, hour int, latest bool) types.DeleteMarkerEntry {
+		return types.DeleteMarkerEntry{
+			Key: aws.String(key), VersionId: aws.String(id), LastModified: at(hour), IsLatest: &latest,
+		}
+	}
+
+	mockS3Client := mocks.NewMockS3APIClient(t)
+	mockS3Client.On("ListObjectVersions", mock.Anything, mock.Anything).Return(&s3.ListObjectVersionsOutput{
+		Versions: []types.ObjectVersion{
+			version("site/changed.html", "c2", 3, true),
+			version("site/changed.html", "c1", 1, false),
+			version("site/unchanged.html", "u1", 1, true),
+			version("site/added.html", "a1", 3, true),
+			version("site/removed.html", "r1", 1, false),
+			version("site/readded.html", "d2", 3, true),
+			version("site/readded.html", "d1", 1, false),
+		},
+		DeleteMarkers: []types.DeleteMarkerEntry{
+			marker("site/removed.html", "rm", 3, true),
+			marker("site/readded.html", "dm", 2, false),
+		},
+	}, nil)
+
+	u := &S3{client: mockS3Client, Bucket: "test-bucket"}
+
+	plan, err := u.PlanRestore(t.Context(), "site", *at(2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"site/changed.html": "c1", "site/removed.html": "r1"}, plan.Versions)
+	assert.Equal(t, []string{"site/added.html", "site/readded.html"}, plan.Deleted)
+}
@@ -0,0 +1,470 @@
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+var ErrUnsafeArchivePath = errors.New("unsafe path in archive")
+
+// archiveExtensions are the extensions of sources whose entries are synced instead of the file itself.
+var archiveExtensions = []string{".tar", ".tar.gz", ".tgz", ".zip"}
+
+// isArchive reports whether the file is an archive by its extension.
+func isArchive(path string) bool {
+	lower := strings.ToLower(path)
+
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const (
+	// maxMemoryEntrySize is the size up to which archive entries are held in memory.
+	maxMemoryEntrySize = 1024 * 1024
+	// maxMemoryArchiveSize is the total size of the entries of an archive held in memory.
+	maxMemoryArchiveSize = 64 * 1024 * 1024
+)
+
+// memoryFile is an archive entry held in memory.
+type memoryFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *memoryFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *memoryFile) Close() error {
+	return nil
+}
+
+// streamFile is an archive entry that is read from the archive again when it is opened. Reads
+// at a later offset skip the content in between, reads at an earlier offset start over.
+type streamFile struct {
+	mu     sync.Mutex
+	open   func() (io.ReadCloser, error)
+	info   fs.FileInfo
+	r      io.ReadCloser
+	offset int64
+}
+
+func (f *streamFile) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.read(p)
+}
+
+func (f *streamFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.seek(off); err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(readerFunc(f.read), p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+func (f *streamFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("%w: negative offset", fs.ErrInvalid)
+	}
+
+	// The entry is only read again at the next read.
+	if offset != f.offset {
+		f.reset()
+		f.offset = offset
+	}
+
+	return offset, nil
+}
+
+func (f *streamFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *streamFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reset()
+
+	return nil
+}
+
+// read reads from the current offset and opens the entry if required.
+func (f *streamFile) read(p []byte) (int, error) {
+	if f.r == nil {
+		r, err := f.open()
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := io.CopyN(io.Discard, r, f.offset); err != nil && !errors.Is(err, io.EOF) {
+			r.Close()
+
+			return 0, err
+		}
+
+		f.r = r
+	}
+
+	n, err := f.r.Read(p)
+	f.offset += int64(n)
+
+	return n, err
+}
+
+// seek moves the offset for the next read. Later offsets skip the content in between.
+func (f *streamFile) seek(offset int64) error {
+	if f.r == nil || offset < f.offset {
+		f.reset()
+		f.offset = offset
+
+		return nil
+	}
+
+	n, err := io.CopyN(io.Discard, f.r, offset-f.offset)
+	f.offset += n
+
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
+
+func (f *streamFile) reset() {
+	if f.r != nil {
+		f.r.Close()
+		f.r = nil
+	}
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (fn readerFunc) Read(p []byte) (int, error) {
+	return fn(p)
+}
+
+// archiveReader collects the regular files of an archive while its entries are read in order.
+type archiveReader struct {
+	p     *Plugin
+	path  string
+	files []sourceFile
+	// index maps the paths to the files, later entries replace earlier entries like on extraction.
+	index map[string]int
+	// sidecars are the options of the sidecar entries by the path of the entry they apply to.
+	sidecars map[string]map[string]string
+	// memory is the total size of the entries held in memory.
+	memory int64
+}
+
+// add hashes a regular file of the archive while it is read and parses sidecar entries. Small
+// entries are held in memory, others are read from the archive again with reopen.
+func (a *archiveReader) add(name string, info fs.FileInfo, r io.Reader, reopen func() (io.ReadCloser, error)) error {
+	rel, err := archivePath(name)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(a.path, rel)
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	if strings.HasSuffix(rel, sidecarSuffix) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		headers, err := parseSidecar(path, data)
+		if err != nil {
+			return err
+		}
+
+		a.sidecars[strings.TrimSuffix(rel, sidecarSuffix)] = headers
+
+		return nil
+	}
+
+	if a.p.skipSource(path) {
+		return nil
+	}
+
+	file := sourceFile{path: path, rel: rel}
+
+	if size := info.Size(); size <= maxMemoryEntrySize && a.memory+size <= maxMemoryArchiveSize {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		a.memory += int64(len(data))
+		sum := sha256.Sum256(data)
+		file.digest = hex.EncodeToString(sum[:])
+		file.open = func() (aws.LocalFile, error) {
+			return &memoryFile{Reader: bytes.NewReader(data), info: info}, nil
+		}
+	} else {
+		if file.digest, err = hashReader(r); err != nil {
+			return err
+		}
+
+		file.open = func() (aws.LocalFile, error) {
+			return &streamFile{open: reopen, info: info}, nil
+		}
+	}
+
+	if i, ok := a.index[rel]; ok {
+		a.files[i] = file
+
+		return nil
+	}
+
+	a.index[rel] = len(a.files)
+	a.files = append(a.files, file)
+
+	return nil
+}
+
+// archiveFiles returns the regular files of a tar or zip archive. The archive is read once to hash
+// its entries, which are read from the archive again when they are uploaded.
+func (p *Plugin) archiveFiles(path string) ([]sourceFile, error) {
+	a := &archiveReader{
+		p:        p,
+		path:     path,
+		index:    make(map[string]int),
+		sidecars: make(map[string]map[string]string),
+	}
+
+	var err error
+
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		err = a.readZip()
+	} else {
+		err = a.readTar()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive '%s': %w", path, err)
+	}
+
+	if len(a.files) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptySourceDirectory, path)
+	}
+
+	for i, file := range a.files {
+		a.files[i].headers = a.sidecars[file.rel]
+	}
+
+	return a.files, nil
+}
+
+// readZip reads the entries of a zip archive. The archive stays open until the end of the sync,
+// since its entries can be read in any order.
+func (a *archiveReader) readZip() error {
+	reader, err := zip.OpenReader(a.path)
+	if err != nil {
+		return err
+	}
+
+	a.p.Settings.archives = append(a.p.Settings.archives, reader)
+
+	for _, entry := range reader.File {
+		r, err := entry.Open()
+		if err != nil {
+			return err
+		}
+
+		err = a.add(entry.Name, entry.FileInfo(), r, entry.Open)
+		r.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readTar reads the entries of a tar archive. Entries that are read again start at the
+// beginning of the archive, since compressed archives can only be read in order.
+func (a *archiveReader) readTar() error {
+	tr, closer, err := openTar(a.path)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	for n := 0; ; n++ {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		reopen := func() (io.ReadCloser, error) {
+			return openTarEntry(a.path, n)
+		}
+
+		if err := a.add(header.Name, header.FileInfo(), tr, reopen); err != nil {
+			return err
+		}
+	}
+}
+
+// tarEntry is the content of a tar entry that closes its archive.
+type tarEntry struct {
+	io.Reader
+	io.Closer
+}
+
+// openTar opens a tar archive, which may be compressed with gzip.
+func openTar(path string) (*tar.Reader, io.Closer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lower := strings.ToLower(path)
+	if !strings.HasSuffix(lower, ".gz") && !strings.HasSuffix(lower, ".tgz") {
+		return tar.NewReader(file), file, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+
+		return nil, nil, err
+	}
+
+	return tar.NewReader(gz), closerFunc(func() error {
+		return errors.Join(gz.Close(), file.Close())
+	}), nil
+}
+
+// openTarEntry opens the nth entry of a tar archive.
+func openTarEntry(path string, n int) (io.ReadCloser, error) {
+	tr, closer, err := openTar(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i <= n; i++ {
+		if _, err := tr.Next(); err != nil {
+			closer.Close()
+
+			return nil, fmt.Errorf("failed to read archive '%s': %w", path, err)
+		}
+	}
+
+	return tarEntry{Reader: tr, Closer: closer}, nil
+}
+
+type closerFunc func() error
+
+func (fn closerFunc) Close() error {
+	return fn()
+}
+
+// archivePath returns the local path of an archive entry. Entries that would be written
+// outside of the target, e.g. `../index.html` or absolute paths, are rejected.
+func archivePath(name string) (string, error) {
+	rel := filepath.FromSlash(name)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+
+	return filepath.Clean(rel), nil
+}
+
+// open opens the local file of the job from disk or from its archive.
+//
+//nolint:ireturn
+func (j Job) open() (aws.LocalFile, error) {
+	if j.opener != nil {
+		return j.opener()
+	}
+
+	return os.Open(j.local)
+}
+
+// stat returns the file info of the local file of the job.
+func (j Job) stat() (fs.FileInfo, error) {
+	file, err := j.open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return file.Stat()
+}
+
+// hash returns the hex encoded SHA-256 hash of the local file of the job. Archive entries
+// are hashed when the archive is read.
+func (j Job) hash() (string, error) {
+	if j.digest != "" {
+		return j.digest, nil
+	}
+
+	file, err := j.open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return hashReader(file)
+}
+
+// closeArchives closes the source archives after the sync.
+func (p *Plugin) closeArchives() {
+	for _, archive := range p.Settings.archives {
+		if err := archive.Close(); err != nil {
+			log.Warn().Msgf("failed to close archive: %v", err)
+		}
+	}
+
+	p.Settings.archives = nil
+}
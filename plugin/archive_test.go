@@ -0,0 +1,255 @@
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+}
+
+func writeTarGz(t *testing.T, path string, entries []archiveEntry) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range entries {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Mode:     0o644,
+			Size:     int64(len(e.content)),
+			Typeflag: tar.TypeReg,
+		}))
+
+		_, err := tw.Write([]byte(e.content))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, file.Close())
+}
+
+func writeZip(t *testing.T, path string, entries []archiveEntry) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+
+	zw := zip.NewWriter(file)
+
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		assert.NoError(t, err)
+
+		_, err = w.Write([]byte(e.content))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, file.Close())
+}
+
+func TestArchiveFiles(t *testing.T) {
+	t.Parallel()
+
+	// Entries larger than the memory limit are read from the archive again.
+	large := strings.Repeat("0123456789", maxMemoryEntrySize/10+1)
+
+	tests := []struct {
+		name    string
+		archive string
+		entries []archiveEntry
+		want    map[string]string
+		wantErr error
+	}{
+		{
+			name:    "tar.gz",
+			archive: "site.tar.gz",
+			entries: []archiveEntry{
+				{name: "./index.html", content: "<html>"},
+				{name: "css/main.css", content: "body {}"},
+				{name: "index.html", content: "<html>new"},
+			},
+			want: map[string]string{"index.html": "<html>new", "css/main.css": "body {}"},
+		},
+		{
+			name:    "zip",
+			archive: "site.zip",
+			entries: []archiveEntry{
+				{name: "index.html", content: "<html>"},
+				{name: "css/", content: ""},
+				{name: "css/main.css", content: "body {}"},
+			},
+			want: map[string]string{"index.html": "<html>", "css/main.css": "body {}"},
+		},
+		{
+			name:    "large entries of tar.gz",
+			archive: "media.tar.gz",
+			entries: []archiveEntry{
+				{name: "video.mp4", content: large},
+				{name: "index.html", content: "<html>"},
+			},
+			want: map[string]string{"video.mp4": large, "index.html": "<html>"},
+		},
+		{
+			name:    "large entries of zip",
+			archive: "media.zip",
+			entries: []archiveEntry{
+				{name: "index.html", content: "<html>"},
+				{name: "video.mp4", content: large},
+			},
+			want: map[string]string{"video.mp4": large, "index.html": "<html>"},
+		},
+		{
+			name:    "error on path traversal",
+			archive: "site.tgz",
+			entries: []archiveEntry{{name: "../index.html", content: "<html>"}},
+			wantErr: ErrUnsafeArchivePath,
+		},
+		{
+			name:    "error on absolute path",
+			archive: "site.zip",
+			entries: []archiveEntry{{name: "/etc/passwd", content: "root"}},
+			wantErr: ErrUnsafeArchivePath,
+		},
+		{
+			name:    "error on empty archive",
+			archive: "site.tar.gz",
+			wantErr: ErrEmptySourceDirectory,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), tt.archive)
+			if filepath.Ext(path) == ".zip" {
+				writeZip(t, path, tt.entries)
+			} else {
+				writeTarGz(t, path, tt.entries)
+			}
+
+			p := &Plugin{Settings: &Settings{}}
+			defer p.closeArchives()
+
+			files, err := p.sourceFiles(path, true)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			got := make(map[string]string, len(files))
+
+			for _, file := range files {
+				assert.Equal(t, filepath.Join(path, file.rel), file.path)
+
+				local, err := file.open()
+				assert.NoError(t, err)
+
+				data, err := io.ReadAll(local)
+				assert.NoError(t, err)
+
+				info, err := local.Stat()
+				assert.NoError(t, err)
+				assert.Equal(t, int64(len(data)), info.Size())
+
+				hash := sha256.Sum256(data)
+				assert.Equal(t, hex.EncodeToString(hash[:]), file.digest)
+
+				got[file.rel] = string(data)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestArchiveFiles_Sidecar(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "site.zip")
+	writeZip(t, path, []archiveEntry{
+		{name: "robots.txt" + sidecarSuffix, content: `{"cacheControl":"no-cache"}`},
+		{name: "robots.txt", content: "User-agent: *"},
+		{name: "index.html", content: "<html>"},
+	})
+
+	p := &Plugin{Settings: &Settings{}}
+	defer p.closeArchives()
+
+	files, err := p.sourceFiles(path, true)
+	assert.NoError(t, err)
+
+	got := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		got[file.rel] = file.headers
+	}
+
+	assert.Equal(t, map[string]map[string]string{
+		"robots.txt": {"Cache-Control": "no-cache"},
+		"index.html": nil,
+	}, got)
+}
+
+func TestStreamFile(t *testing.T) {
+	t.Parallel()
+
+	content := "0123456789"
+	opens := 0
+
+	f := &streamFile{
+		open: func() (io.ReadCloser, error) {
+			opens++
+
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+	}
+	defer f.Close()
+
+	buf := make([]byte, 3)
+
+	n, err := f.ReadAt(buf, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "234", string(buf[:n]))
+
+	// Later offsets continue the open entry.
+	n, err = f.ReadAt(buf, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "789", string(buf[:n]))
+	assert.Equal(t, 1, opens)
+
+	// Earlier offsets read the entry again.
+	n, err = f.ReadAt(buf, 8)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "89", string(buf[:n]))
+	assert.Equal(t, 2, opens)
+
+	_, err = f.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+	assert.Equal(t, 3, opens)
+}
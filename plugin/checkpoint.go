@@ -0,0 +1,402 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+// checkpointFlushInterval is the minimum time between two writes of completed jobs.
+const checkpointFlushInterval = 5 * time.Second
+
+// checkpoint records completed jobs in a local file so that an interrupted sync with the
+// same plan can skip confirmed work. A nil checkpoint disables all recording.
+type checkpoint struct {
+	mu      sync.Mutex
+	path    string
+	flushed time.Time
+	dirty   bool
+
+	Plan    string                      `json:"plan"`
+	Entries map[string]*checkpointEntry `json:"entries"`
+
+	// stale are unfinished multipart uploads that were dropped from the checkpoint.
+	stale []staleUpload
+}
+
+// staleUpload is an unfinished multipart upload that can no longer be resumed.
+type staleUpload struct {
+	remote   string
+	uploadID string
+}
+
+// checkpointEntry is a job recorded in the checkpoint. Uploads are identified by the size,
+// modification time and hash of the local file.
+type checkpointEntry struct {
+	Action    string              `json:"action"`
+	Remote    string              `json:"remote"`
+	Size      int64               `json:"size,omitempty"`
+	ModTime   time.Time           `json:"modTime,omitzero"`
+	Hash      string              `json:"hash,omitempty"`
+	Done      bool                `json:"done"`
+	Multipart *aws.MultipartState `json:"multipart,omitempty"`
+}
+
+// checkpointMultipart stores the multipart state of a single upload in the checkpoint.
+type checkpointMultipart struct {
+	c       *checkpoint
+	job     Job
+	size    int64
+	modTime time.Time
+}
+
+// loadCheckpoint reads the checkpoint file. A missing file or a file written for a
+// different plan results in an empty checkpoint.
+func loadCheckpoint(path, plan string) (*checkpoint, error) {
+	c := &checkpoint{
+		path:    path,
+		flushed: time.Now(),
+		Plan:    plan,
+		Entries: make(map[string]*checkpointEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	stored := &checkpoint{}
+	if err := json.Unmarshal(data, stored); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+
+	if stored.Plan != plan {
+		log.Info().Msgf("Ignoring checkpoint '%s' because it was created for a different plan", path)
+
+		c.stale = stored.unfinished()
+
+		return c, nil
+	}
+
+	if stored.Entries != nil {
+		c.Entries = stored.Entries
+	}
+
+	log.Info().Msgf("Resuming from checkpoint '%s' with %d recorded jobs", path, len(c.Entries))
+
+	return c, nil
+}
+
+// checkpointKey returns the key of a job, uploads and redirects are keyed by the local path.
+func checkpointKey(job Job) string {
+	if job.local == "" || job.action == "copy" || job.action == "restore" {
+		return job.action + ":" + job.remote
+	}
+
+	return job.action + ":" + job.local
+}
+
+// Done reports whether the job was completed by a previous run and the local file is unchanged.
+func (c *checkpoint) Done(job Job) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	entry, ok := c.Entries[checkpointKey(job)]
+	c.mu.Unlock()
+
+	if !ok || !entry.Done || entry.Remote != job.remote {
+		return false
+	}
+
+	if job.action != "upload" {
+		return true
+	}
+
+	info, err := job.stat()
+	if err != nil || info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+		return false
+	}
+
+	hash, err := job.hash()
+
+	return err == nil && hash == entry.Hash
+}
+
+// Complete records the job as done.
+func (c *checkpoint) Complete(job Job) error {
+	if c == nil {
+		return nil
+	}
+
+	entry := &checkpointEntry{
+		Action: job.action,
+		Remote: job.remote,
+		Done:   true,
+	}
+
+	if job.action == "upload" {
+		info, err := job.stat()
+		if err != nil {
+			return err
+		}
+
+		hash, err := job.hash()
+		if err != nil {
+			return err
+		}
+
+		entry.Size = info.Size()
+		entry.ModTime = info.ModTime()
+		entry.Hash = hash
+	}
+
+	c.mu.Lock()
+	c.Entries[checkpointKey(job)] = entry
+	c.dirty = true
+	flush := time.Since(c.flushed) >= checkpointFlushInterval
+	c.mu.Unlock()
+
+	if flush {
+		return c.Flush()
+	}
+
+	return nil
+}
+
+// Multipart returns the store for the multipart state of an upload job.
+//
+//nolint:ireturn
+func (c *checkpoint) Multipart(job Job) aws.MultipartStore {
+	if c == nil {
+		return nil
+	}
+
+	info, err := job.stat()
+	if err != nil {
+		return nil
+	}
+
+	return &checkpointMultipart{c: c, job: job, size: info.Size(), modTime: info.ModTime()}
+}
+
+// Flush writes the checkpoint file if jobs were recorded since the last write.
+func (c *checkpoint) Flush() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil { //nolint:mnd
+		return err
+	}
+
+	if err := os.WriteFile(tmp, data, 0o600); err != nil { //nolint:mnd
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	c.dirty = false
+	c.flushed = time.Now()
+
+	return nil
+}
+
+// Remove deletes the checkpoint file after a successful sync.
+func (c *checkpoint) Remove() error {
+	if c == nil {
+		return nil
+	}
+
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// unfinished returns the multipart uploads of the checkpoint that were not completed.
+func (c *checkpoint) unfinished() []staleUpload {
+	var uploads []staleUpload
+
+	for _, entry := range c.Entries {
+		if entry.Multipart != nil {
+			uploads = append(uploads, staleUpload{remote: entry.Remote, uploadID: entry.Multipart.UploadID})
+		}
+	}
+
+	return uploads
+}
+
+// Abort aborts the multipart uploads that were dropped from the checkpoint. With unfinished set,
+// all uploads still recorded are aborted as well, e.g. before the checkpoint is removed.
+func (c *checkpoint) Abort(ctx context.Context, client *aws.Client, unfinished bool) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	uploads := c.stale
+	c.stale = nil
+
+	if unfinished {
+		uploads = append(uploads, c.unfinished()...)
+	}
+
+	c.mu.Unlock()
+
+	for _, upload := range uploads {
+		log.Debug().Msgf("aborting stale upload of '%s'", upload.remote)
+
+		if err := client.S3.AbortMultipart(ctx, upload.remote, upload.uploadID); err != nil {
+			log.Warn().Msgf("failed to abort stale upload of '%s': %v", upload.remote, err)
+		}
+	}
+}
+
+func (m *checkpointMultipart) Load() *aws.MultipartState {
+	m.c.mu.Lock()
+	defer m.c.mu.Unlock()
+
+	entry, ok := m.c.Entries[checkpointKey(m.job)]
+	if !ok || entry.Multipart == nil {
+		return nil
+	}
+
+	// The upload was started for a different file and is aborted with the stale uploads.
+	if entry.Remote != m.job.remote || entry.Size != m.size || !entry.ModTime.Equal(m.modTime) {
+		m.c.stale = append(m.c.stale, staleUpload{remote: entry.Remote, uploadID: entry.Multipart.UploadID})
+		entry.Multipart = nil
+		m.c.dirty = true
+
+		return nil
+	}
+
+	return entry.Multipart
+}
+
+func (m *checkpointMultipart) Save(state *aws.MultipartState) {
+	m.c.mu.Lock()
+	m.c.Entries[checkpointKey(m.job)] = &checkpointEntry{
+		Action:    m.job.action,
+		Remote:    m.job.remote,
+		Size:      m.size,
+		ModTime:   m.modTime,
+		Multipart: state,
+	}
+	m.c.dirty = true
+	m.c.mu.Unlock()
+
+	// Parts are large, writing the checkpoint after every part keeps the progress of
+	// uploads that are interrupted before the next regular flush.
+	if err := m.c.Flush(); err != nil {
+		log.Warn().Msgf("failed to save multipart state of '%s': %v", m.job.local, err)
+	}
+}
+
+// hashFile returns the hex encoded SHA-256 hash of the file.
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return hashReader(file)
+}
+
+// hashReader returns the hex encoded SHA-256 hash of the content.
+func hashReader(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// planFingerprint identifies the settings that determine the jobs of a sync. A checkpoint
+// is only reused for the same plan.
+func (p *Plugin) planFingerprint() string {
+	var headers string
+	if p.Settings.HeadersFile != "" {
+		headers, _ = hashFile(p.headersFilePath())
+	}
+
+	// Options of sidecar files are read while the jobs are created.
+	sidecars := make(map[string]map[string]string)
+
+	for _, job := range p.Settings.Jobs {
+		if len(job.headers) > 0 {
+			sidecars[job.remote] = job.headers
+		}
+	}
+
+	data, _ := json.Marshal([]any{
+		p.Settings.Endpoint,
+		p.Settings.Bucket,
+		p.Settings.Source,
+		p.Settings.SourceArchive,
+		p.Settings.Target,
+		p.Settings.Delete,
+		p.Settings.ACL,
+		p.Settings.CacheControl,
+		p.Settings.ContentType,
+		p.Settings.ContentEncoding,
+		p.Settings.Metadata,
+		p.Settings.Redirects,
+		p.Settings.MultipartPartSize,
+		p.Settings.ChecksumAlgorithm,
+		p.Settings.ReleaseAliases,
+		p.Settings.ContentDisposition,
+		p.Settings.ContentLanguage,
+		p.Settings.Expires,
+		p.Settings.WebsiteRedirect,
+		headers,
+		sidecars,
+		p.Settings.Compare,
+		p.Settings.ACLMode,
+		p.Settings.Provenance,
+		p.Settings.ObjectLockMode,
+		p.Settings.ObjectLockRetention,
+		p.Settings.ObjectLockLegalHold,
+		p.Settings.ObjectLockFiles,
+		p.Settings.Restore,
+		p.Settings.DetectMoves,
+		p.Settings.Mappings,
+	})
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
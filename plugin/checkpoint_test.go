@@ -0,0 +1,172 @@
+package plugin
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+func TestCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoint.json")
+	local := filepath.Join(dir, "file.txt")
+	_ = os.WriteFile(local, []byte("hello"), 0o600)
+
+	upload := Job{local: local, remote: "target/file.txt", action: "upload"}
+	del := Job{remote: "target/old.txt", action: "delete"}
+
+	cp, err := loadCheckpoint(path, "plan-1")
+	assert.NoError(t, err)
+	assert.False(t, cp.Done(upload))
+
+	assert.NoError(t, cp.Complete(upload))
+	assert.NoError(t, cp.Complete(del))
+	assert.NoError(t, cp.Flush())
+
+	cp, err = loadCheckpoint(path, "plan-1")
+	assert.NoError(t, err)
+	assert.True(t, cp.Done(upload))
+	assert.True(t, cp.Done(del))
+	assert.False(t, cp.Done(Job{local: local, remote: "target/other.txt", action: "upload"}))
+
+	_ = os.WriteFile(local, []byte("changed"), 0o600)
+	assert.False(t, cp.Done(upload))
+
+	cp, err = loadCheckpoint(path, "plan-2")
+	assert.NoError(t, err)
+	assert.False(t, cp.Done(del))
+
+	assert.NoError(t, cp.Remove())
+	assert.NoFileExists(t, path)
+}
+
+func TestCheckpoint_Multipart(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoint.json")
+	local := filepath.Join(dir, "file.bin")
+	_ = os.WriteFile(local, []byte("hello"), 0o600)
+
+	job := Job{local: local, remote: "target/file.bin", action: "upload"}
+	state := &aws.MultipartState{UploadID: "upload-1", PartSize: aws.MinPartSize}
+
+	cp, _ := loadCheckpoint(path, "plan-1")
+	cp.Multipart(job).Save(state)
+
+	cp, err := loadCheckpoint(path, "plan-1")
+	assert.NoError(t, err)
+	assert.Equal(t, state, cp.Multipart(job).Load())
+	assert.False(t, cp.Done(job))
+
+	_ = os.WriteFile(local, []byte("changed"), 0o600)
+	assert.Nil(t, cp.Multipart(job).Load())
+	assert.Equal(t, []staleUpload{{remote: "target/file.bin", uploadID: "upload-1"}}, cp.stale)
+}
+
+func TestCheckpoint_Abort(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoint.json")
+	local := filepath.Join(dir, "file.bin")
+	_ = os.WriteFile(local, []byte("hello"), 0o600)
+
+	job := Job{local: local, remote: "target/file.bin", action: "upload"}
+
+	cp, _ := loadCheckpoint(path, "plan-1")
+	cp.Multipart(job).Save(&aws.MultipartState{UploadID: "upload-1", PartSize: aws.MinPartSize})
+
+	var (
+		mu      sync.Mutex
+		aborted []string
+	)
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == http.MethodDelete {
+			aborted = append(aborted, r.URL.Path+"?"+r.URL.Query().Get("uploadId"))
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// Uploads of a different plan are never resumed.
+	cp, err := loadCheckpoint(path, "plan-2")
+	assert.NoError(t, err)
+
+	cp.Abort(t.Context(), client, false)
+	assert.Equal(t, []string{"/bucket/target/file.bin?upload-1"}, aborted)
+
+	// Unfinished uploads are only aborted with the checkpoint.
+	aborted = nil
+
+	cp, _ = loadCheckpoint(path, "plan-1")
+	cp.Abort(t.Context(), client, false)
+	assert.Empty(t, aborted)
+
+	cp.Abort(t.Context(), client, true)
+	assert.Equal(t, []string{"/bucket/target/file.bin?upload-1"}, aborted)
+}
+
+func TestCheckpoint_Disabled(t *testing.T) {
+	t.Parallel()
+
+	var cp *checkpoint
+
+	job := Job{local: "file.txt", remote: "file.txt", action: "upload"}
+
+	assert.False(t, cp.Done(job))
+	assert.NoError(t, cp.Complete(job))
+	assert.Nil(t, cp.Multipart(job))
+	assert.NoError(t, cp.Flush())
+	assert.NoError(t, cp.Remove())
+}
+
+func TestPlanFingerprint(t *testing.T) {
+	t.Parallel()
+
+	base := Settings{Source: "dist", Target: "site"}
+	plan := (&Plugin{Settings: &base}).planFingerprint()
+
+	tests := []struct {
+		name     string
+		settings Settings
+	}{
+		{
+			name:     "compare mode",
+			settings: Settings{Source: "dist", Target: "site", Compare: "size"},
+		},
+		{
+			name:     "acl mode",
+			settings: Settings{Source: "dist", Target: "site", ACLMode: "disabled"},
+		},
+		{
+			name:     "provenance",
+			settings: Settings{Source: "dist", Target: "site", Provenance: true},
+		},
+		{
+			name: "sidecar options",
+			settings: Settings{Source: "dist", Target: "site", Jobs: []Job{
+				{remote: "site/index.html", action: "upload", headers: map[string]string{"Cache-Control": "no-cache"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.NotEqual(t, plan, (&Plugin{Settings: &tt.settings}).planFingerprint())
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// throttleCooldown is the minimum time between two concurrency reductions. Throttling
+// responses of requests that were already in flight are not counted twice.
+const throttleCooldown = time.Second
+
+// concurrencyLimiter limits the number of concurrently running jobs. The limit is halved
+// when the storage backend throttles requests and grows back by one after a full window
+// of successful jobs.
+type concurrencyLimiter struct {
+	mu        sync.Mutex
+	changed   chan struct{}
+	max       int
+	limit     int
+	active    int
+	successes int
+	lastDrop  time.Time
+
+	throttled int
+	minLimit  int
+}
+
+// concurrencyStats summarizes the behavior of the limiter after a sync.
+type concurrencyStats struct {
+	Throttled  int
+	MinLimit   int
+	FinalLimit int
+}
+
+func newConcurrencyLimiter(limit int) *concurrencyLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+
+	return &concurrencyLimiter{
+		changed:  make(chan struct{}),
+		max:      limit,
+		limit:    limit,
+		minLimit: limit,
+	}
+}
+
+// Acquire blocks until a job slot is available or the context is canceled.
+func (l *concurrencyLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+
+		if l.active < l.limit {
+			l.active++
+			l.mu.Unlock()
+
+			return nil
+		}
+
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Release frees a job slot. Successful jobs count towards growing the limit again.
+func (l *concurrencyLimiter) Release(success bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+
+	if success {
+		l.successes++
+
+		if l.limit < l.max && l.successes >= l.limit {
+			l.limit++
+			l.successes = 0
+		}
+	}
+
+	l.notify()
+}
+
+// Throttle reduces the limit in response to a throttling error.
+func (l *concurrencyLimiter) Throttle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.throttled++
+	l.successes = 0
+
+	if time.Since(l.lastDrop) < throttleCooldown {
+		return
+	}
+
+	l.lastDrop = time.Now()
+	l.limit = max(1, l.limit/2) //nolint:mnd
+
+	l.minLimit = min(l.minLimit, l.limit)
+}
+
+// Stats returns the throttling statistics of the limiter.
+func (l *concurrencyLimiter) Stats() concurrencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return concurrencyStats{
+		Throttled:  l.throttled,
+		MinLimit:   l.minLimit,
+		FinalLimit: l.limit,
+	}
+}
+
+// notify wakes up all goroutines waiting in Acquire. The caller must hold the lock.
+func (l *concurrencyLimiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
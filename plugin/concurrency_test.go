@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	t.Parallel()
+
+	l := newConcurrencyLimiter(8)
+
+	for range 8 {
+		assert.NoError(t, l.Acquire(t.Context()))
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, l.Acquire(ctx), context.DeadlineExceeded)
+
+	l.Throttle()
+	l.Throttle()
+
+	stats := l.Stats()
+	assert.Equal(t, 2, stats.Throttled)
+	assert.Equal(t, 4, stats.MinLimit)
+	assert.Equal(t, 4, stats.FinalLimit)
+
+	for range 8 {
+		l.Release(true)
+	}
+
+	assert.Equal(t, 5, l.Stats().FinalLimit)
+}
+
+func TestConcurrencyLimiter_MinimumLimit(t *testing.T) {
+	t.Parallel()
+
+	l := newConcurrencyLimiter(0)
+
+	assert.NoError(t, l.Acquire(t.Context()))
+	l.Throttle()
+	l.Release(false)
+
+	assert.Equal(t, 1, l.Stats().FinalLimit)
+	assert.NoError(t, l.Acquire(t.Context()))
+}
@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+var ErrInvalidHeadersFile = errors.New("invalid headers file")
+
+// headerRule assigns headers to all paths matching the pattern.
+type headerRule struct {
+	pattern *regexp.Regexp
+	headers map[string]string
+}
+
+// placeholderPattern matches `:name` placeholders of a path pattern.
+var placeholderPattern = regexp.MustCompile(`:[A-Za-z_][A-Za-z0-9_]*`)
+
+// loadHeadersFile parses a headers file in the format used by Netlify. A path pattern starts
+// at the beginning of a line and is followed by indented `Name: value` lines. `*` matches any
+// part of the path and `:name` a single path segment.
+func loadHeadersFile(path string) ([]headerRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read headers file: %w", err)
+	}
+	defer file.Close()
+
+	var (
+		rules  []headerRule
+		rule   *headerRule
+		number int
+	)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		number++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if line == trimmed {
+			rules = append(rules, headerRule{pattern: compilePathPattern(trimmed), headers: make(map[string]string)})
+			rule = &rules[len(rules)-1]
+
+			continue
+		}
+
+		name, value, ok := strings.Cut(trimmed, ":")
+		if !ok || rule == nil {
+			return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidHeadersFile, number, trimmed)
+		}
+
+		name, value = http.CanonicalHeaderKey(strings.TrimSpace(name)), strings.TrimSpace(value)
+		if err := aws.ValidateHeader(name, value); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidHeadersFile, number, err)
+		}
+
+		rule.headers[name] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read headers file: %w", err)
+	}
+
+	return rules, nil
+}
+
+// compilePathPattern converts a path pattern to a regular expression.
+func compilePathPattern(pattern string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, `.*`)
+	expr = placeholderPattern.ReplaceAllString(expr, `[^/]+`)
+
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// matchHeaderRules returns the headers of all rules matching the path. Later rules take
+// precedence over earlier ones.
+func matchHeaderRules(rules []headerRule, path string) map[string]string {
+	var headers map[string]string
+
+	for _, rule := range rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+
+		for name, value := range rule.headers {
+			headers[name] = value
+		}
+	}
+
+	return headers
+}
@@ -0,0 +1,128 @@
+package plugin
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+func TestLoadHeadersFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		want    map[string]string
+		wantErr error
+	}{
+		{
+			name: "merge matching rules",
+			content: `# Assets are immutable
+/assets/*
+  Cache-Control: public, max-age=31536000, immutable
+
+/assets/:name.js
+  cache-control: no-cache
+  X-Amz-Meta-Owner: frontend
+`,
+			path: "/assets/app.js",
+			want: map[string]string{
+				"Cache-Control":    "no-cache",
+				"X-Amz-Meta-Owner": "frontend",
+			},
+		},
+		{
+			name: "wildcard matches nested paths",
+			content: `/downloads/*
+  Content-Disposition: attachment
+`,
+			path: "/downloads/v1/app.zip",
+			want: map[string]string{"Content-Disposition": "attachment"},
+		},
+		{
+			name: "placeholder does not match nested paths",
+			content: `/docs/:page
+  Content-Language: en
+`,
+			path: "/docs/v1/index.html",
+			want: nil,
+		},
+		{
+			name: "error on unsupported header",
+			content: `/*
+  X-Frame-Options: DENY
+`,
+			wantErr: aws.ErrUnsupportedHeader,
+		},
+		{
+			name: "error on header without path",
+			content: `  Cache-Control: no-cache
+`,
+			wantErr: ErrInvalidHeadersFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "_headers")
+			_ = os.WriteFile(path, []byte(tt.content), 0o600)
+
+			rules, err := loadHeadersFile(path)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, matchHeaderRules(rules, tt.path))
+		})
+	}
+}
+
+func TestRunJob_HeaderRules(t *testing.T) {
+	t.Parallel()
+
+	uploaded := make(chan string, 1)
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		uploaded <- r.Header.Get("Cache-Control")
+	})
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "app.js")
+	path := filepath.Join(dir, "_headers")
+
+	_ = os.WriteFile(local, []byte("hello"), 0o600)
+	_ = os.WriteFile(path, []byte("/assets/*\n  Cache-Control: immutable\n"), 0o600)
+
+	rules, err := loadHeadersFile(path)
+	assert.NoError(t, err)
+
+	p := &Plugin{Settings: &Settings{Target: "site"}}
+	state := &syncState{client: client, headers: rules}
+
+	// The path of the rule is relative to the target of the mapping, not to the target of the step.
+	err = p.runJob(t.Context(), state, Job{
+		local:   local,
+		remote:  "site/docs/assets/app.js",
+		action:  "upload",
+		mapping: &mapping{target: "site/docs"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "immutable", <-uploaded)
+}
@@ -4,19 +4,51 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/thegeeklab/wp-s3-action/aws"
 )
 
+// manifestName is the name of the deploy manifest object below the target.
+const manifestName = ".s3-action-manifest.json"
+
 var (
-	ErrTypeAssertionFailed  = errors.New("type assertion failed")
-	ErrEmptySourceDirectory = errors.New("source directory is empty")
+	ErrTypeAssertionFailed    = errors.New("type assertion failed")
+	ErrEmptySourceDirectory   = errors.New("source directory is empty")
+	ErrEmptyReleaseVersion    = errors.New("release version is empty")
+	ErrReleaseVersionRequired = errors.New("release aliases require a release version")
+	ErrInvalidRestoreTime     = errors.New("invalid restore time")
+	ErrConcurrentChanges      = errors.New("objects were modified concurrently")
 )
 
+// syncState holds the state shared by all jobs of a sync.
+type syncState struct {
+	client     *aws.Client
+	limiter    *concurrencyLimiter
+	checkpoint *checkpoint
+	// previous is the manifest of the last successful sync, manifest records the current one.
+	previous *aws.Manifest
+	manifest *aws.Manifest
+	sums     *checksumFile
+	signer   *signingKey
+	headers  []headerRule
+	lock     *aws.ObjectLock
+
+	mu       sync.Mutex
+	verified []string
+	// conflicts are the keys that were not written because they were modified concurrently.
+	conflicts []string
+	// touched are the keys of all jobs that were run, their noncurrent versions are pruned.
+	touched map[string]bool
+}
+
 // Execute provides the implementation of the plugin.
 func (p *Plugin) run(ctx context.Context) error {
 	if err := p.Validate(); err != nil {
@@ -37,16 +69,110 @@ func (p *Plugin) Validate() error {
 		return fmt.Errorf("error while retrieving working directory: %w", err)
 	}
 
+	for _, value := range p.Settings.Expires {
+		if _, err := aws.ParseExpires(value); err != nil {
+			return err
+		}
+	}
+
+	if err := p.validateObjectLock(); err != nil {
+		return err
+	}
+
+	if p.Settings.Restore != "" {
+		if _, err := time.Parse(time.RFC3339, p.Settings.Restore); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidRestoreTime, p.Settings.Restore)
+		}
+	}
+
+	// An empty version is an error if it only became empty after the expansion.
+	release := p.Settings.ReleaseVersion
+
+	if err := p.expandSettings(); err != nil {
+		return err
+	}
+
 	p.Settings.Source = filepath.Join(wd, p.Settings.Source)
 	p.Settings.Target = strings.TrimPrefix(p.Settings.Target, "/")
+	p.Settings.root = p.Settings.Target
+
+	if err := p.resolveRelease(release); err != nil {
+		return err
+	}
+
+	p.Settings.mappings, err = p.resolveMappings(wd)
+
+	return err
+}
+
+// resolveRelease moves the target to the release version and resolves the aliases relative
+// to the original target.
+func (p *Plugin) resolveRelease(release string) error {
+	if release == "" {
+		if len(p.Settings.ReleaseAliases) > 0 {
+			return ErrReleaseVersionRequired
+		}
+
+		return nil
+	}
+
+	version := strings.Trim(p.Settings.ReleaseVersion, "/")
+	if version == "" {
+		return fmt.Errorf("%w: %s", ErrEmptyReleaseVersion, release)
+	}
+
+	// Files are uploaded to the version prefix, aliases are resolved relative to the original target.
+	for i, alias := range p.Settings.ReleaseAliases {
+		p.Settings.ReleaseAliases[i] = filepath.Join(p.Settings.Target, strings.Trim(alias, "/"))
+	}
+
+	p.Settings.Target = filepath.Join(p.Settings.Target, version)
+
+	return nil
+}
+
+// expandSettings expands templates in the target, release prefixes, redirect locations
+// and metadata values.
+func (p *Plugin) expandSettings() error {
+	var err error
+
+	if p.Settings.Target, err = expandTemplate(p.Settings.Target); err != nil {
+		return err
+	}
+
+	if p.Settings.ReleaseVersion, err = expandTemplate(p.Settings.ReleaseVersion); err != nil {
+		return err
+	}
+
+	for i, alias := range p.Settings.ReleaseAliases {
+		if p.Settings.ReleaseAliases[i], err = expandTemplate(alias); err != nil {
+			return err
+		}
+	}
+
+	for path, location := range p.Settings.Redirects {
+		if p.Settings.Redirects[path], err = expandTemplate(location); err != nil {
+			return err
+		}
+	}
+
+	for _, metadata := range p.Settings.Metadata {
+		for key, value := range metadata {
+			if metadata[key], err = expandTemplate(value); err != nil {
+				return err
+			}
+		}
+	}
 
 	return nil
 }
 
 // Execute provides the implementation of the plugin.
-func (p *Plugin) Execute() error {
+func (p *Plugin) Execute() (err error) {
 	p.Settings.Jobs = make([]Job, 1)
 
+	limiter := newConcurrencyLimiter(p.Settings.MaxConcurrency)
+
 	client, err := aws.NewClient(
 		p.Network.Context,
 		p.Settings.Endpoint,
@@ -55,182 +181,795 @@ func (p *Plugin) Execute() error {
 		p.Settings.SecretKey,
 		p.Settings.PathStyle,
 		p.Settings.ChecksumCalculation,
+		aws.RetryOptions{
+			MaxAttempts: p.Settings.RetryMaxAttempts,
+			MaxBackoff:  p.Settings.RetryMaxBackoff,
+			OnThrottle:  limiter.Throttle,
+		},
 	)
 	if err != nil {
 		return fmt.Errorf("error while creating AWS client: %w", err)
 	}
 
+	bandwidth, err := aws.ParseBandwidth(p.Settings.MaxBandwidth)
+	if err != nil {
+		return err
+	}
+
+	partSize, err := aws.ParsePartSize(p.Settings.MultipartPartSize)
+	if err != nil {
+		return err
+	}
+
+	var compare aws.CompareMode
+
+	if err := compare.Set(p.Settings.Compare); err != nil {
+		return err
+	}
+
+	checksumAlgorithm, err := p.checksumAlgorithm()
+	if err != nil {
+		return err
+	}
+
+	var verify aws.VerifyMode
+
+	if err := verify.Set(p.Settings.Verify); err != nil {
+		return err
+	}
+
 	client.S3.Bucket = p.Settings.Bucket
 	client.S3.DryRun = p.Settings.DryRun
+	client.S3.Bandwidth = aws.NewBandwidthLimiter(bandwidth)
+	client.S3.PartSize = partSize
+	client.S3.Compare = compare
+	client.S3.ChecksumAlgorithm = checksumAlgorithm
+	client.S3.VerifyMode = verify
+	client.S3.ConditionalWrites = p.Settings.ConditionalWrites
+
+	client.S3.DefaultCharset = p.Settings.DefaultCharset
+
+	if err := client.S3.ACLMode.Set(p.Settings.ACLMode); err != nil {
+		return err
+	}
+
+	if client.S3.ACLMode == aws.ACLModeAuto {
+		if client.S3.ACLMode, err = client.S3.DetectACLMode(p.Network.Context); err != nil {
+			return fmt.Errorf("error while detecting ACL mode: %w", err)
+		}
+
+		log.Debug().Msgf("using ACL mode '%s'", client.S3.ACLMode)
+	}
+
+	if err := client.S3.ACLErrorPolicy.Set(p.Settings.ACLErrorPolicy); err != nil {
+		return err
+	}
+
+	if p.Settings.MIMETypesFile != "" {
+		if client.S3.MIMETypes, err = aws.LoadMIMETypes(p.Settings.MIMETypesFile); err != nil {
+			return err
+		}
+	}
+
+	if p.Settings.Provenance {
+		client.S3.Provenance = provenanceMetadata(time.Now())
+	}
 
 	client.Cloudfront.Distribution = p.Settings.CloudFrontDistribution
 
-	if err := p.createSyncJobs(p.Network.Context, client); err != nil {
+	state := &syncState{
+		client:  client,
+		limiter: limiter,
+	}
+
+	if state.lock, err = p.objectLock(time.Now()); err != nil {
+		return err
+	}
+
+	// Locked objects are refused before they are overwritten or deleted instead of failing the
+	// request. Locks are only checked if files are locked and the bucket has Object Lock enabled.
+	if state.lock != nil {
+		if client.S3.CheckObjectLock, err = client.S3.DetectObjectLock(p.Network.Context); err != nil {
+			return fmt.Errorf("error while detecting object lock: %w", err)
+		}
+	}
+
+	lock, err := p.acquireLock(p.Network.Context, client)
+	if err != nil {
+		return fmt.Errorf("error while acquiring lock: %w", err)
+	}
+
+	// The sync is canceled if the lock is taken over while it is refreshed.
+	ctx, stopRefresh := p.refreshLock(p.Network.Context, client, lock)
+
+	// The lock is released even if the sync was canceled.
+	defer func() {
+		if lost := stopRefresh(); lost != nil && err != nil {
+			err = fmt.Errorf("%w: %w", lost, err)
+		}
+
+		if err := client.S3.ReleaseLock(context.Background(), lock); err != nil {
+			log.Warn().Msgf("failed to release lock: %v", err)
+		}
+	}()
+
+	// A restore writes the versions of the restored point in time, including the manifest and checksum file.
+	restore := p.Settings.Restore != ""
+
+	if p.Settings.Manifest && !restore {
+		state.manifest = aws.NewManifest()
+
+		// Without the previous manifest every object is checked with a HEAD request.
+		if !p.Settings.ManifestVerify {
+			opt := aws.S3ManifestOptions{RemoteObjectKey: p.manifestKey()}
+
+			state.previous, err = client.S3.GetManifest(ctx, opt)
+			if err != nil {
+				return fmt.Errorf("error while reading manifest: %w", err)
+			}
+		}
+	}
+
+	if p.Settings.ChecksumFile != "" && !restore {
+		state.sums = newChecksumFile(p.Settings.ChecksumFileFormat)
+
+		if p.Settings.ChecksumFileSigningKey != "" {
+			if state.signer, err = parseSigningKey(p.Settings.ChecksumFileSigningKey); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.Settings.HeadersFile != "" && !restore {
+		if state.headers, err = loadHeadersFile(p.headersFilePath()); err != nil {
+			return err
+		}
+	}
+
+	// Archives are read by the upload jobs until the sync is done.
+	defer p.closeArchives()
+
+	if restore {
+		if err := p.createRestoreJobs(ctx, client); err != nil {
+			return fmt.Errorf("error while creating restore job: %w", err)
+		}
+	} else if err := p.createSyncJobs(ctx, client, state.previous); err != nil {
 		return fmt.Errorf("error while creating sync job: %w", err)
 	}
 
 	if len(p.Settings.CloudFrontDistribution) > 0 {
-		p.Settings.Jobs = append(p.Settings.Jobs, Job{
-			local:  "",
-			remote: filepath.Join("/", p.Settings.Target, "*"),
-			action: "invalidateCloudFront",
-		})
+		for _, prefix := range append([]string{p.Settings.Target}, p.Settings.ReleaseAliases...) {
+			p.Settings.Jobs = append(p.Settings.Jobs, Job{
+				local:  "",
+				remote: filepath.Join("/", prefix, "*"),
+				action: "invalidateCloudFront",
+			})
+		}
 	}
 
-	if err := p.runJobs(p.Network.Context, client); err != nil {
+	// A dry run does not perform any changes that could be recorded.
+	if p.Settings.Checkpoint != "" && !p.Settings.DryRun {
+		state.checkpoint, err = loadCheckpoint(p.Settings.Checkpoint, p.planFingerprint())
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := p.runJobs(ctx, state); err != nil {
+		state.checkpoint.Abort(ctx, client, false)
+
+		if flushErr := state.checkpoint.Flush(); flushErr != nil {
+			log.Warn().Msgf("failed to save checkpoint: %v", flushErr)
+		}
+
 		return fmt.Errorf("error while running jobs: %w", err)
 	}
 
+	state.reportVerified()
+
+	if err := p.pruneVersions(ctx, state); err != nil {
+		return fmt.Errorf("error while pruning versions: %w", err)
+	}
+
+	if state.manifest != nil {
+		opt := aws.S3ManifestOptions{RemoteObjectKey: p.manifestKey()}
+
+		if err := client.S3.PutManifest(ctx, opt, state.manifest); err != nil {
+			return fmt.Errorf("error while writing manifest: %w", err)
+		}
+	}
+
+	// Uploads of files that were removed since the interrupted sync are never resumed.
+	state.checkpoint.Abort(ctx, client, true)
+
+	if err := state.checkpoint.Remove(); err != nil {
+		return fmt.Errorf("error while removing checkpoint: %w", err)
+	}
+
 	return nil
 }
 
-func (p *Plugin) createSyncJobs(ctx context.Context, client *aws.Client) error {
-	remote, err := client.S3.List(ctx, aws.S3ListOptions{Path: p.Settings.Target})
+// manifestKey returns the object key of the deploy manifest.
+func (p *Plugin) manifestKey() string {
+	return filepath.Join(p.Settings.Target, manifestName)
+}
+
+// headersFilePath returns the local path of the headers file.
+func (p *Plugin) headersFilePath() string {
+	return filepath.Join(p.Settings.Source, p.Settings.HeadersFile)
+}
+
+// checksumFileKey returns the object key of the checksum file.
+func (p *Plugin) checksumFileKey() string {
+	return filepath.Join(p.Settings.Target, p.Settings.ChecksumFile)
+}
+
+// checksumName returns the name of an object in the checksum file. Names are relative to the
+// directory of the checksum file, so the files can be checked from there.
+func (p *Plugin) checksumName(key string) string {
+	name, err := filepath.Rel(filepath.Dir(p.checksumFileKey()), key)
 	if err != nil {
-		return err
+		return key
 	}
 
-	entries, err := os.ReadDir(p.Settings.Source)
-	if err != nil {
-		return fmt.Errorf("failed to read source directory: %w", err)
+	return filepath.ToSlash(name)
+}
+
+// keyPrefix returns the prefix of all keys below the path. The prefix ends with a slash to not
+// match sibling paths like `v1.2.30` for `v1.2.3`, the bucket root is the empty prefix.
+func keyPrefix(path string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return ""
 	}
 
-	if len(entries) == 0 {
-		if !p.Settings.AllowEmptySource {
-			return fmt.Errorf("%w: %s", ErrEmptySourceDirectory, p.Settings.Source)
-		}
+	return path + "/"
+}
 
-		log.Warn().Msgf("%s: %s", ErrEmptySourceDirectory, p.Settings.Source)
+// generatedKey reports whether the object is written by the plugin itself and must not
+// be deleted during the sync.
+func (p *Plugin) generatedKey(key string) bool {
+	if p.Settings.Lock && key == p.lockKey() {
+		return true
 	}
 
-	local := make([]string, 0)
+	if p.Settings.Manifest && key == p.manifestKey() {
+		return true
+	}
+
+	if p.Settings.ChecksumFile != "" {
+		return key == p.checksumFileKey() || key == p.checksumFileKey()+signatureSuffix
+	}
+
+	return false
+}
+
+// putChecksumFile uploads the checksum file and its signature beside the uploaded files.
+func (p *Plugin) putChecksumFile(ctx context.Context, state *syncState) error {
+	if state.sums == nil {
+		return nil
+	}
+
+	opt := aws.S3PutOptions{
+		LocalFilePath: filepath.Join(p.Settings.Source, p.Settings.ChecksumFile),
+		ACL:           p.Settings.ACL,
+		ContentType:   "text/plain; charset=utf-8",
+		Body:          state.sums.Bytes(),
+	}
+
+	var signature []byte
+	if state.signer != nil {
+		signature = state.signer.Sign(p.Settings.ChecksumFile, opt.Body, time.Now())
+	}
 
-	err = filepath.Walk(p.Settings.Source, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
+	// Aliases mirror the release version including the checksum file.
+	for _, prefix := range append([]string{p.Settings.Target}, p.Settings.ReleaseAliases...) {
+		opt.RemoteObjectKey = filepath.Join(prefix, p.Settings.ChecksumFile)
+
+		if err := state.client.S3.Put(ctx, opt); err != nil {
 			return err
 		}
 
-		localPath := path
-		if p.Settings.Source != "." {
-			localPath = strings.TrimPrefix(path, p.Settings.Source)
-			localPath = strings.TrimPrefix(localPath, "/")
+		if signature == nil {
+			continue
 		}
 
-		local = append(local, localPath)
+		sigOpt := opt
+		sigOpt.LocalFilePath += signatureSuffix
+		sigOpt.RemoteObjectKey += signatureSuffix
+		sigOpt.Body = signature
 
-		p.Settings.Jobs = append(p.Settings.Jobs, Job{
-			local:  filepath.Join(p.Settings.Source, localPath),
-			remote: filepath.Join(p.Settings.Target, localPath),
-			action: "upload",
-		})
+		if err := state.client.S3.Put(ctx, sigOpt); err != nil {
+			return err
+		}
+	}
 
-		return nil
-	})
-	if err != nil {
-		return err
+	return nil
+}
+
+func (p *Plugin) createSyncJobs(ctx context.Context, client *aws.Client, previous *aws.Manifest) error {
+	var remote []aws.S3Object
+
+	// Objects below the target that are part of the sync, redirects are not deleted below the target.
+	expected := make(map[string]bool)
+
+	for path := range p.Settings.Redirects {
+		expected[filepath.Join(p.Settings.Target, strings.TrimPrefix(path, "/"))] = true
+	}
+
+	for _, m := range p.Settings.mappings {
+		objects, err := client.S3.ListObjects(ctx, aws.S3ListOptions{Path: keyPrefix(m.target)})
+		if err != nil {
+			return err
+		}
+
+		remote = append(remote, objects...)
+
+		if err := p.createUploadJobs(m, expected); err != nil {
+			return err
+		}
 	}
 
 	for path, location := range p.Settings.Redirects {
-		path = strings.TrimPrefix(path, "/")
-		local = append(local, path)
 		p.Settings.Jobs = append(p.Settings.Jobs, Job{
-			local:  path,
+			local:  strings.TrimPrefix(path, "/"),
 			remote: location,
 			action: "redirect",
 		})
 	}
 
-	if p.Settings.Delete {
-		for _, remote := range remote {
-			found := false
-			remotePath := strings.TrimPrefix(remote, p.Settings.Target+"/")
+	sources, err := p.assignCopySources(client, previous, remote)
+	if err != nil {
+		return err
+	}
+
+	deleted := make(map[string]bool)
 
-			for _, l := range local {
-				if l == remotePath {
-					found = true
+	for _, m := range p.Settings.mappings {
+		if !m.delete {
+			continue
+		}
 
-					break
-				}
+		for _, obj := range remote {
+			key := obj.Key
+
+			// Listings of nested mapping targets overlap.
+			if !strings.HasPrefix(key, keyPrefix(m.target)) || expected[key] || deleted[key] || p.generatedKey(key) {
+				continue
 			}
 
-			if !found {
-				p.Settings.Jobs = append(p.Settings.Jobs, Job{
-					local:  "",
-					remote: remote,
-					action: "delete",
-				})
+			deleted[key] = true
+
+			p.Settings.Jobs = append(p.Settings.Jobs, Job{
+				local:    "",
+				remote:   key,
+				action:   "delete",
+				deferred: sources[key],
+			})
+		}
+	}
+
+	return p.createAliasJobs(ctx, client)
+}
+
+// createUploadJobs adds the upload jobs of the files of a mapping and records their keys as expected.
+func (p *Plugin) createUploadJobs(m *mapping, expected map[string]bool) error {
+	files, err := p.sourceFiles(m.source, m.archive)
+	if err != nil {
+		if !errors.Is(err, ErrEmptySourceDirectory) || !p.Settings.AllowEmptySource {
+			return err
+		}
+
+		log.Warn().Msg(err.Error())
+	}
+
+	for _, file := range files {
+		key := filepath.Join(m.target, file.rel)
+		if expected[key] {
+			return fmt.Errorf("%w: %s", ErrMappingConflict, key)
+		}
+
+		expected[key] = true
+
+		headers := file.headers
+
+		// Sidecar entries of archives are read with the archive.
+		if _, err := os.Stat(file.path + sidecarSuffix); err == nil && file.open == nil {
+			if headers, err = loadSidecar(file.path + sidecarSuffix); err != nil {
+				return err
 			}
 		}
+
+		p.Settings.Jobs = append(p.Settings.Jobs, Job{
+			local:   file.path,
+			remote:  key,
+			action:  "upload",
+			headers: headers,
+			mapping: m,
+			opener:  file.open,
+			digest:  file.digest,
+		})
 	}
 
 	return nil
 }
 
-func (p *Plugin) runJobs(ctx context.Context, client *aws.Client) error {
-	jobChan := make(chan struct{}, p.Settings.MaxConcurrency)
-	results := make(chan *Result, len(p.Settings.Jobs))
+// createAliasJobs adds jobs that copy all uploaded files from the release version to each
+// alias prefix and remove alias objects that are not part of the release.
+func (p *Plugin) createAliasJobs(ctx context.Context, client *aws.Client) error {
+	uploads := make([]Job, 0)
+
+	for _, job := range p.Settings.Jobs {
+		if job.action == "upload" {
+			uploads = append(uploads, job)
+		}
+	}
+
+	for _, alias := range p.Settings.ReleaseAliases {
+		remote, err := client.S3.List(ctx, aws.S3ListOptions{Path: keyPrefix(alias)})
+		if err != nil {
+			return err
+		}
+
+		expected := make(map[string]bool)
+
+		if p.Settings.ChecksumFile != "" {
+			expected[filepath.Join(alias, p.Settings.ChecksumFile)] = true
+			expected[filepath.Join(alias, p.Settings.ChecksumFile+signatureSuffix)] = true
+		}
+
+		for _, job := range uploads {
+			key := filepath.Join(alias, strings.TrimPrefix(job.remote, p.Settings.Target+"/"))
+			expected[key] = true
+
+			p.Settings.Jobs = append(p.Settings.Jobs, Job{
+				local:    job.local,
+				remote:   key,
+				action:   "copy",
+				source:   job.remote,
+				deferred: true,
+			})
+		}
 
-	var invalidateJob *Job
+		for _, key := range remote {
+			if expected[key] {
+				continue
+			}
+
+			p.Settings.Jobs = append(p.Settings.Jobs, Job{
+				local:    "",
+				remote:   key,
+				action:   "delete",
+				deferred: true,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (p *Plugin) runJobs(ctx context.Context, state *syncState) error {
+	results := make(chan *Result, len(p.Settings.Jobs))
+	invalidateJobs := make([]Job, 0)
+	pending := 0
+	deferred := false
 
 	log.Info().Msgf("Synchronizing with bucket '%s'", p.Settings.Bucket)
 
+	defer func() {
+		stats := state.limiter.Stats()
+		if stats.Throttled > 0 {
+			log.Info().Msgf(
+				"Requests were throttled %d times, concurrency was reduced to %d and ended at %d",
+				stats.Throttled, stats.MinLimit, stats.FinalLimit,
+			)
+		}
+	}()
+
+	// The jobs still running are canceled after the first failure. All jobs are awaited before
+	// returning, the lock must not be released while requests are in flight.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wait := func() error {
+		var err error
+
+		for ; pending > 0; pending-- {
+			r := <-results
+			if r.err == nil {
+				continue
+			}
+
+			// Jobs canceled because of the failure are not reported instead of the failure.
+			if err == nil || (errors.Is(err, context.Canceled) && !errors.Is(r.err, context.Canceled)) {
+				err = fmt.Errorf("failed to %s %s to %s: %w", r.j.action, r.j.local, r.j.remote, r.err)
+			}
+		}
+
+		return err
+	}
+
 	for _, job := range p.Settings.Jobs {
-		jobChan <- struct{}{}
+		if job.action == "invalidateCloudFront" {
+			invalidateJobs = append(invalidateJobs, job)
+
+			continue
+		}
+
+		// Aliases are only updated once the release version is complete and copy sources
+		// are only deleted once they were copied.
+		if job.deferred && !deferred {
+			if err := wait(); err != nil {
+				return err
+			}
+
+			// Aliases and copy sources are not touched if objects were modified concurrently.
+			if err := state.conflictError(); err != nil {
+				return err
+			}
+
+			deferred = true
+		}
+
+		if err := state.limiter.Acquire(ctx); err != nil {
+			cancel()
+
+			if waitErr := wait(); waitErr != nil {
+				return waitErr
+			}
+
+			return err
+		}
+
+		pending++
 
 		go func(job Job) {
-			var err error
-
-			switch job.action {
-			case "upload":
-				opt := aws.S3UploadOptions{
-					LocalFilePath:   job.local,
-					RemoteObjectKey: job.remote,
-					ACL:             p.Settings.ACL,
-					ContentType:     p.Settings.ContentType,
-					ContentEncoding: p.Settings.ContentEncoding,
-					CacheControl:    p.Settings.CacheControl,
-					Metadata:        p.Settings.Metadata,
-				}
-				err = client.S3.Upload(ctx, opt)
-			case "redirect":
-				opt := aws.S3RedirectOptions{
-					Path:     job.local,
-					Location: job.remote,
-				}
-				err = client.S3.Redirect(ctx, opt)
-			case "delete":
-				opt := aws.S3DeleteOptions{
-					RemoteObjectKey: job.remote,
-				}
-				err = client.S3.Delete(ctx, opt)
-			case "invalidateCloudFront":
-				invalidateJob = &job
-			default:
+			err := p.runJob(ctx, state, job)
+
+			// Conflicts do not stop the sync, they are reported after all jobs are done.
+			if errors.Is(err, aws.ErrConflict) {
+				state.conflict(job)
+
 				err = nil
 			}
 
+			if err != nil {
+				cancel()
+			}
+
 			results <- &Result{job, err}
 
-			<-jobChan
+			state.limiter.Release(err == nil)
 		}(job)
 	}
 
-	for range p.Settings.Jobs {
-		r := <-results
-		if r.err != nil {
-			return fmt.Errorf("failed to %s %s to %s: %w", r.j.action, r.j.local, r.j.remote, r.err)
-		}
+	if err := wait(); err != nil {
+		return err
+	}
+
+	if err := state.conflictError(); err != nil {
+		return err
+	}
+
+	// The checksum file is written before the invalidation to not serve a stale version.
+	if err := p.putChecksumFile(ctx, state); err != nil {
+		return fmt.Errorf("failed to write checksum file: %w", err)
 	}
 
-	if invalidateJob != nil {
+	for _, job := range invalidateJobs {
 		opt := aws.CloudfrontInvalidateOptions{
-			Path: invalidateJob.remote,
+			Path: job.remote,
 		}
 
-		err := client.Cloudfront.Invalidate(ctx, opt)
+		err := state.client.Cloudfront.Invalidate(ctx, opt)
 		if err != nil {
-			return fmt.Errorf("failed to %s %s to %s: %w", invalidateJob.action, invalidateJob.local, invalidateJob.remote, err)
+			return fmt.Errorf("failed to %s %s to %s: %w", job.action, job.local, job.remote, err)
+		}
+	}
+
+	return nil
+}
+
+// runJob executes a single sync job. Jobs already completed according to the checkpoint
+// or unchanged according to the manifest are skipped, but still recorded in the new manifest.
+func (p *Plugin) runJob(ctx context.Context, state *syncState, job Job) error {
+	var (
+		key = job.remote
+		obj aws.ManifestObject
+		err error
+	)
+
+	// Jobs without a mapping, e.g. copies to release aliases, use the settings of the step.
+	opt := p.uploadOptions()
+	if job.mapping != nil {
+		opt = job.mapping.options
+	}
+
+	opt.LocalFilePath = job.local
+	opt.RemoteObjectKey = job.remote
+	opt.Open = job.opener
+
+	switch job.action {
+	case "upload":
+		// The hash identifies the file in the checkpoint and the checksum file, it is only computed once.
+		if job.digest == "" && (state.checkpoint != nil || state.sums != nil) {
+			if job.digest, err = job.hash(); err != nil {
+				return err
+			}
+		}
+
+		opt.Headers = matchHeaderRules(state.headers, "/"+p.mappingPath(job))
+
+		if len(job.headers) > 0 {
+			if opt.Headers == nil {
+				opt.Headers = make(map[string]string, len(job.headers))
+			}
+
+			maps.Copy(opt.Headers, job.headers)
+		}
+
+		if state.lock != nil && p.objectLocked(p.mappingPath(job)) {
+			opt.ObjectLock = state.lock
+		}
+
+		opt.SourceObjectKey = job.source
+
+		if state.manifest != nil {
+			if obj, err = state.client.S3.Describe(opt); err != nil {
+				return err
+			}
+		}
+	case "redirect":
+		key = job.local
+		obj = aws.ManifestObject{RedirectLocation: job.remote}
+	case "copy", "restore", "delete":
+	default:
+		return nil
+	}
+
+	// Only uploads and redirects are recorded in the manifest.
+	recorded := job.action == "upload" || job.action == "redirect"
+
+	prev, unchanged := state.previous.Get(key)
+	unchanged = unchanged && recorded && prev.Equal(obj)
+
+	switch {
+	case unchanged:
+		log.Debug().Msgf("skipping %s of '%s', unchanged according to manifest", job.action, key)
+	case state.checkpoint.Done(job):
+		log.Debug().Msgf("skipping %s of '%s', already completed according to checkpoint", job.action, key)
+		state.touch(key)
+	default:
+		opt.Multipart = state.checkpoint.Multipart(job)
+
+		// Only objects written by this run are verified, unchanged objects are skipped by the upload.
+		var written bool
+
+		opt.Written = func() { written = true }
+
+		if err := p.execJob(ctx, state.client, job, opt); err != nil {
+			return err
+		}
+
+		if written {
+			if err := p.verifyJob(ctx, state, job); err != nil {
+				return err
+			}
+		}
+
+		if err := state.checkpoint.Complete(job); err != nil {
+			return err
 		}
+
+		state.touch(key)
+	}
+
+	if recorded {
+		state.manifest.Set(key, obj)
+	}
+
+	if job.action == "upload" {
+		return state.sums.Add(p.checksumName(job.remote), job)
+	}
+
+	return nil
+}
+
+// verifyJob checks the uploaded object against the local file if verification is enabled.
+func (p *Plugin) verifyJob(ctx context.Context, state *syncState, job Job) error {
+	if job.action != "upload" || p.Settings.Verify == "" || p.Settings.DryRun {
+		return nil
+	}
+
+	err := state.client.S3.Verify(ctx, aws.S3VerifyOptions{
+		LocalFilePath:   job.local,
+		RemoteObjectKey: job.remote,
+		Open:            job.opener,
+	})
+	if err != nil {
+		return err
+	}
+
+	state.mu.Lock()
+	state.verified = append(state.verified, job.remote)
+	state.mu.Unlock()
+
+	return nil
+}
+
+// conflict records the key of a job that was not run because the object was modified concurrently.
+func (s *syncState) conflict(job Job) {
+	key := job.remote
+	if job.action == "redirect" {
+		key = job.local
+	}
+
+	s.mu.Lock()
+	s.conflicts = append(s.conflicts, key)
+	s.mu.Unlock()
+}
+
+// conflictError logs the keys of all conflicts and returns ErrConcurrentChanges if there are any.
+func (s *syncState) conflictError() error {
+	if len(s.conflicts) == 0 {
+		return nil
+	}
+
+	sort.Strings(s.conflicts)
+
+	log.Warn().Msgf("%d objects were modified concurrently and not written", len(s.conflicts))
+
+	for _, key := range s.conflicts {
+		log.Warn().Msgf("conflict on '%s'", key)
+	}
+
+	return fmt.Errorf("%w: %d objects", ErrConcurrentChanges, len(s.conflicts))
+}
+
+// reportVerified logs the keys of all verified objects.
+func (s *syncState) reportVerified() {
+	if len(s.verified) == 0 {
+		return
+	}
+
+	sort.Strings(s.verified)
+
+	log.Info().Msgf("Verified %d objects", len(s.verified))
+
+	for _, key := range s.verified {
+		log.Info().Msgf("verified '%s'", key)
+	}
+}
+
+// execJob sends the requests of a single sync job.
+func (p *Plugin) execJob(ctx context.Context, client *aws.Client, job Job, opt aws.S3UploadOptions) error {
+	switch job.action {
+	case "upload":
+		return client.S3.Upload(ctx, opt)
+	case "redirect":
+		return client.S3.Redirect(ctx, aws.S3RedirectOptions{
+			Path:     job.local,
+			Location: job.remote,
+		})
+	case "copy":
+		return client.S3.Copy(ctx, aws.S3CopyOptions{
+			LocalFilePath:   job.local,
+			SourceObjectKey: job.source,
+			RemoteObjectKey: job.remote,
+			ACL:             opt.ACL,
+		})
+	case "restore":
+		return client.S3.Copy(ctx, aws.S3CopyOptions{
+			LocalFilePath:   job.local,
+			SourceObjectKey: job.remote,
+			RemoteObjectKey: job.remote,
+			ACL:             opt.ACL,
+			SourceVersionID: job.version,
+		})
+	case "delete":
+		return client.S3.Delete(ctx, aws.S3DeleteOptions{
+			RemoteObjectKey: job.remote,
+		})
 	}
 
 	return nil
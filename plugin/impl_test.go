@@ -0,0 +1,211 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+// newTestClient returns a client for the bucket `bucket` that sends all requests to the handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *aws.Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := aws.NewClient(
+		t.Context(), server.URL, "us-east-1", "key", "secret", true, string(aws.ChecksumRequired),
+		aws.RetryOptions{MaxAttempts: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.S3.Bucket = "bucket"
+
+	return client
+}
+
+func TestValidateRelease(t *testing.T) {
+	tests := []struct {
+		name        string
+		envs        map[string]string
+		settings    Settings
+		wantTarget  string
+		wantAliases []string
+		wantLock    string
+		wantErr     error
+	}{
+		{
+			name:       "without release version",
+			settings:   Settings{Target: "/releases"},
+			wantTarget: "releases",
+			wantLock:   "releases/.s3-action-lock.json",
+		},
+		{
+			name: "expand release version and aliases",
+			envs: map[string]string{"CI_COMMIT_TAG": "v1.2.3"},
+			settings: Settings{
+				Target:         "/releases",
+				ReleaseVersion: "${CI_COMMIT_TAG}",
+				ReleaseAliases: []string{"latest", "/stable/"},
+			},
+			wantTarget:  "releases/v1.2.3",
+			wantLock:    "releases/.s3-action-lock.json",
+			wantAliases: []string{"releases/latest", "releases/stable"},
+		},
+		{
+			name:     "error on empty release version",
+			envs:     map[string]string{"CI_COMMIT_TAG": ""},
+			settings: Settings{Target: "releases", ReleaseVersion: "${CI_COMMIT_TAG}"},
+			wantErr:  ErrEmptyReleaseVersion,
+		},
+		{
+			name:     "error on aliases without release version",
+			settings: Settings{Target: "releases", ReleaseAliases: []string{"latest"}},
+			wantErr:  ErrReleaseVersionRequired,
+		},
+		{
+			name:       "restore time",
+			settings:   Settings{Target: "site", Restore: "2024-01-02T03:04:05Z"},
+			wantTarget: "site",
+			wantLock:   "site/.s3-action-lock.json",
+		},
+		{
+			name:     "error on invalid restore time",
+			settings: Settings{Target: "site", Restore: "yesterday"},
+			wantErr:  ErrInvalidRestoreTime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.envs {
+				t.Setenv(key, value)
+			}
+
+			p := &Plugin{Settings: &tt.settings}
+
+			err := p.Validate()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantTarget, p.Settings.Target)
+			assert.Equal(t, tt.wantAliases, p.Settings.ReleaseAliases)
+			assert.Equal(t, tt.wantLock, p.lockKey())
+		})
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "", want: ""},
+		{path: "/", want: ""},
+		{path: "releases/v1.2.3", want: "releases/v1.2.3/"},
+		{path: "/site/", want: "site/"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, keyPrefix(tt.path), tt.path)
+	}
+}
+
+func TestRunJobs_CancelOnFailure(t *testing.T) {
+	t.Parallel()
+
+	canceled := make(chan bool, 1)
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/slow") {
+			select {
+			case <-r.Context().Done():
+				canceled <- true
+			case <-time.After(5 * time.Second):
+				canceled <- false
+			}
+
+			return
+		}
+
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>denied</Message></Error>`))
+	})
+
+	p := &Plugin{Settings: &Settings{
+		Jobs: []Job{
+			{remote: "slow", action: "delete"},
+			{remote: "fail", action: "delete"},
+		},
+	}}
+	state := &syncState{client: client, limiter: newConcurrencyLimiter(2)}
+
+	err := p.runJobs(t.Context(), state)
+
+	// The failure is reported instead of the cancellation of the slow job, which has finished.
+	assert.ErrorContains(t, err, "AccessDenied")
+	assert.NotErrorIs(t, err, context.Canceled)
+	assert.True(t, <-canceled)
+}
+
+func TestRunJobs_Conflicts(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"etag"`)
+
+		if r.Method != http.MethodDelete {
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/modified") {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_, _ = w.Write([]byte(`<Error><Code>PreconditionFailed</Code><Message>failed</Message></Error>`))
+
+			return
+		}
+
+		mu.Lock()
+		deleted = append(deleted, r.URL.Path)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client.S3.ConditionalWrites = true
+
+	p := &Plugin{Settings: &Settings{
+		Jobs: []Job{
+			{remote: "modified", action: "delete"},
+			{remote: "unchanged", action: "delete"},
+			{remote: "source", action: "delete", deferred: true},
+		},
+	}}
+	state := &syncState{client: client, limiter: newConcurrencyLimiter(1)}
+
+	err := p.runJobs(t.Context(), state)
+
+	// The conflict does not stop the other jobs, but deferred jobs are not run.
+	assert.ErrorIs(t, err, ErrConcurrentChanges)
+	assert.Equal(t, []string{"modified"}, state.conflicts)
+	assert.Equal(t, []string{"/bucket/unchanged"}, deleted)
+}
@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+// lockName is the name of the lock object below the target.
+const lockName = ".s3-action-lock.json"
+
+// lockRetryInterval is the time between attempts to acquire a held lock.
+const lockRetryInterval = 10 * time.Second
+
+// lockRefreshes is the number of times the lock is refreshed within its TTL.
+const lockRefreshes = 3
+
+// lockKey returns the object key of the lock. All release versions share the lock of the
+// original target as they update the same aliases.
+func (p *Plugin) lockKey() string {
+	return filepath.Join(p.Settings.root, lockName)
+}
+
+// acquireLock creates the lock object below the target. A held lock is retried until the lock
+// timeout expires. Nil is returned if locking is disabled or nothing is written.
+func (p *Plugin) acquireLock(ctx context.Context, client *aws.Client) (*aws.Lock, error) {
+	if !p.Settings.Lock || p.Settings.DryRun {
+		return nil, nil //nolint:nilnil
+	}
+
+	owner := os.Getenv("CI_REPO")
+	if owner == "" {
+		owner, _ = os.Hostname()
+	}
+
+	opt := aws.S3LockOptions{
+		RemoteObjectKey: p.lockKey(),
+		Owner:           owner,
+		Pipeline:        os.Getenv("CI_PIPELINE_NUMBER"),
+		TTL:             p.Settings.LockTTL,
+	}
+	deadline := time.Now().Add(p.Settings.LockTimeout)
+
+	for {
+		lock, err := client.S3.AcquireLock(ctx, opt)
+		if !errors.Is(err, aws.ErrLockHeld) || time.Now().After(deadline) {
+			return lock, err
+		}
+
+		log.Info().Msgf("Waiting for lock '%s': %v", opt.RemoteObjectKey, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// refreshLock extends the lock periodically while the sync runs, a sync taking longer than the TTL
+// must not be taken over. The returned context is canceled if the lock was lost. The returned
+// function stops the refresh and returns the error if the lock was lost.
+func (p *Plugin) refreshLock(ctx context.Context, client *aws.Client, lock *aws.Lock) (context.Context, func() error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	interval := p.Settings.LockTTL / lockRefreshes
+	if lock == nil || interval <= 0 {
+		return ctx, func() error {
+			cancel(nil)
+
+			return nil
+		}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := client.S3.RefreshLock(ctx, lock)
+			if errors.Is(err, aws.ErrLockLost) {
+				cancel(err)
+
+				return
+			}
+
+			if err != nil {
+				log.Warn().Msgf("failed to refresh lock: %v", err)
+			}
+		}
+	}()
+
+	return ctx, func() error {
+		close(done)
+		<-stopped
+
+		lost := context.Cause(ctx)
+		cancel(nil)
+
+		if errors.Is(lost, aws.ErrLockLost) {
+			return lost
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+func TestRefreshLock(t *testing.T) {
+	t.Parallel()
+
+	// The lock is created, but taken over before it is refreshed.
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == "*" {
+			w.Header().Set("ETag", `"1"`)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusPreconditionFailed)
+		_, _ = w.Write([]byte(`<Error><Code>PreconditionFailed</Code><Message>changed</Message></Error>`))
+	})
+
+	p := &Plugin{Settings: &Settings{Target: "site", Lock: true, LockTTL: 30 * time.Millisecond}}
+
+	lock, err := p.acquireLock(t.Context(), client)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, stop := p.refreshLock(t.Context(), client, lock)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync was not canceled after the lock was lost")
+	}
+
+	assert.ErrorIs(t, stop(), aws.ErrLockLost)
+}
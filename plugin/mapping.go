@@ -0,0 +1,309 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+var (
+	ErrInvalidMappings = errors.New("invalid mappings")
+	ErrMappingConflict = errors.New("object is written by multiple mappings")
+	ErrNoArchiveSource = errors.New("source is not a tar or zip archive")
+)
+
+// Mapping syncs a source to a prefix below the target with its own upload options.
+type Mapping struct {
+	Source  string         `json:"source"`
+	Target  string         `json:"target"`
+	Options MappingOptions `json:"options"`
+}
+
+// MappingOptions override the settings of the step for the files of a mapping.
+type MappingOptions struct {
+	Delete             *bool                        `json:"delete"`
+	SourceArchive      *bool                        `json:"source_archive"`
+	ACL                stringMap                    `json:"acl"`
+	CacheControl       stringMap                    `json:"cache_control"`
+	ContentType        stringMap                    `json:"content_type"`
+	ContentEncoding    stringMap                    `json:"content_encoding"`
+	ContentDisposition stringMap                    `json:"content_disposition"`
+	ContentLanguage    stringMap                    `json:"content_language"`
+	Expires            stringMap                    `json:"expires"`
+	WebsiteRedirect    stringMap                    `json:"website_redirect"`
+	Metadata           map[string]map[string]string `json:"metadata"`
+}
+
+// stringMap accepts a map of patterns or a single value for all files like the map settings.
+type stringMap map[string]string
+
+func (m *stringMap) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*m = stringMap{"*": value}
+
+		return nil
+	}
+
+	return json.Unmarshal(data, (*map[string]string)(m))
+}
+
+// mapping is a resolved source and target pair of the sync.
+type mapping struct {
+	source string
+	target string
+	delete bool
+	// archive syncs the entries of the archive source instead of the archive.
+	archive bool
+	options aws.S3UploadOptions
+}
+
+// mappingPath returns the object key of the job relative to the target of its mapping. Jobs
+// without a mapping are relative to the target of the step.
+func (p *Plugin) mappingPath(job Job) string {
+	target := p.Settings.Target
+	if job.mapping != nil {
+		target = job.mapping.target
+	}
+
+	return strings.TrimPrefix(job.remote, target+"/")
+}
+
+// uploadOptions returns the upload settings of the step.
+func (p *Plugin) uploadOptions() aws.S3UploadOptions {
+	return aws.S3UploadOptions{
+		ACL:             p.Settings.ACL,
+		ContentType:     p.Settings.ContentType,
+		ContentEncoding: p.Settings.ContentEncoding,
+		CacheControl:    p.Settings.CacheControl,
+		Metadata:        p.Settings.Metadata,
+
+		ContentDisposition: p.Settings.ContentDisposition,
+		ContentLanguage:    p.Settings.ContentLanguage,
+		Expires:            p.Settings.Expires,
+		WebsiteRedirect:    p.Settings.WebsiteRedirect,
+	}
+}
+
+// resolveMappings parses the mappings. Sources are relative to the working directory, targets are
+// relative to the target. Without mappings the source is synced to the target.
+func (p *Plugin) resolveMappings(wd string) ([]*mapping, error) {
+	if p.Settings.Mappings == "" {
+		return []*mapping{{
+			source:  p.Settings.Source,
+			target:  p.Settings.Target,
+			delete:  p.Settings.Delete,
+			archive: p.Settings.SourceArchive,
+			options: p.uploadOptions(),
+		}}, nil
+	}
+
+	var mappings []Mapping
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(p.Settings.Mappings)))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&mappings); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMappings, err)
+	}
+
+	resolved := make([]*mapping, 0, len(mappings))
+
+	for i, m := range mappings {
+		if m.Source == "" {
+			return nil, fmt.Errorf("%w: mapping %d has no source", ErrInvalidMappings, i+1)
+		}
+
+		target, err := expandTemplate(m.Target)
+		if err != nil {
+			return nil, err
+		}
+
+		// Targets are relative to the target of the step and must not leave it, e.g. with `../`.
+		target = strings.Trim(target, "/")
+		if target != "" && !filepath.IsLocal(target) {
+			return nil, fmt.Errorf("%w: target of mapping %d leaves the target: %s", ErrInvalidMappings, i+1, m.Target)
+		}
+
+		if err := m.Options.expand(); err != nil {
+			return nil, err
+		}
+
+		resolved = append(resolved, &mapping{
+			source:  filepath.Join(wd, m.Source),
+			target:  filepath.Join(p.Settings.Target, target),
+			delete:  p.Settings.Delete,
+			archive: p.Settings.SourceArchive,
+			options: p.uploadOptions(),
+		})
+
+		m.Options.apply(resolved[i])
+	}
+
+	return resolved, nil
+}
+
+// expand validates the expiry dates and expands templates in the metadata values.
+func (o MappingOptions) expand() error {
+	for _, value := range o.Expires {
+		if _, err := aws.ParseExpires(value); err != nil {
+			return err
+		}
+	}
+
+	var err error
+
+	for _, metadata := range o.Metadata {
+		for key, value := range metadata {
+			if metadata[key], err = expandTemplate(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// apply overrides the inherited settings of the mapping with the set options.
+func (o MappingOptions) apply(m *mapping) {
+	if o.Delete != nil {
+		m.delete = *o.Delete
+	}
+
+	if o.SourceArchive != nil {
+		m.archive = *o.SourceArchive
+	}
+
+	for dst, src := range map[*map[string]string]stringMap{
+		&m.options.ACL:                o.ACL,
+		&m.options.CacheControl:       o.CacheControl,
+		&m.options.ContentType:        o.ContentType,
+		&m.options.ContentEncoding:    o.ContentEncoding,
+		&m.options.ContentDisposition: o.ContentDisposition,
+		&m.options.ContentLanguage:    o.ContentLanguage,
+		&m.options.Expires:            o.Expires,
+		&m.options.WebsiteRedirect:    o.WebsiteRedirect,
+	} {
+		if src != nil {
+			*dst = src
+		}
+	}
+
+	if o.Metadata != nil {
+		m.options.Metadata = o.Metadata
+	}
+}
+
+// sourceFile is a local file and its path relative to the target of its mapping.
+type sourceFile struct {
+	path string
+	rel  string
+	// open reads archive entries, files on disk are opened by their path.
+	open aws.OpenFunc
+	// digest is the SHA-256 hash of an archive entry.
+	digest string
+	// headers are the options of the sidecar entry of an archive entry.
+	headers map[string]string
+}
+
+// sourceFiles returns the files of a source, which is a directory, a single file or a glob pattern.
+// Paths of glob matches are relative to the directory before the first pattern element. The entries
+// of an archive source are only returned if enabled.
+func (p *Plugin) sourceFiles(source string, archive bool) ([]sourceFile, error) {
+	if archive {
+		if info, err := os.Stat(source); err != nil || info.IsDir() || !isArchive(source) {
+			return nil, fmt.Errorf("%w: %s", ErrNoArchiveSource, source)
+		}
+
+		return p.archiveFiles(source)
+	}
+
+	if !strings.ContainsAny(source, "*?[") {
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source: %w", err)
+		}
+
+		if !info.IsDir() {
+			return []sourceFile{{path: source, rel: filepath.Base(source)}}, nil
+		}
+
+		entries, err := os.ReadDir(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source directory: %w", err)
+		}
+
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrEmptySourceDirectory, source)
+		}
+
+		return p.walkSource(source, source, nil)
+	}
+
+	matches, err := filepath.Glob(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source pattern '%s': %w", source, err)
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptySourceDirectory, source)
+	}
+
+	base := globBase(source)
+	files := make([]sourceFile, 0, len(matches))
+
+	for _, match := range matches {
+		if files, err = p.walkSource(base, match, files); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// walkSource appends all files below the root with paths relative to the base.
+func (p *Plugin) walkSource(base, root string, files []sourceFile) ([]sourceFile, error) {
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		if p.skipSource(path) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, sourceFile{path: path, rel: rel})
+
+		return nil
+	})
+
+	return files, err
+}
+
+// skipSource reports whether the file is the headers file or a sidecar file, which only configure
+// the upload and are not published.
+func (p *Plugin) skipSource(path string) bool {
+	return (p.Settings.HeadersFile != "" && path == p.headersFilePath()) || strings.HasSuffix(path, sidecarSuffix)
+}
+
+// globBase returns the directory of a glob pattern before its first pattern element.
+func globBase(pattern string) string {
+	base := pattern
+
+	for strings.ContainsAny(base, "*?[") {
+		base = filepath.Dir(base)
+	}
+
+	return base
+}
@@ -0,0 +1,191 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+func TestResolveMappings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		want     []*mapping
+		wantErr  error
+	}{
+		{
+			name:     "default mapping",
+			settings: Settings{Source: "/src", Target: "site", Delete: true, ACL: map[string]string{"*": "private"}},
+			want: []*mapping{{
+				source:  "/src",
+				target:  "site",
+				delete:  true,
+				options: aws.S3UploadOptions{ACL: map[string]string{"*": "private"}},
+			}},
+		},
+		{
+			name: "mappings with options",
+			settings: Settings{
+				Target: "site",
+				Delete: true,
+				ACL:    map[string]string{"*": "private"},
+				Mappings: `[{"source": "docs", "target": "/docs/"}, {"source": "dist/*.zip", "target": "downloads",` +
+					` "options": {"delete": false, "cache_control": "no-cache", "acl": {"*.zip": "public-read"}}}]`,
+			},
+			want: []*mapping{
+				{
+					source:  "/wd/docs",
+					target:  "site/docs",
+					delete:  true,
+					options: aws.S3UploadOptions{ACL: map[string]string{"*": "private"}},
+				},
+				{
+					source: "/wd/dist/*.zip",
+					target: "site/downloads",
+					options: aws.S3UploadOptions{
+						ACL:          map[string]string{"*.zip": "public-read"},
+						CacheControl: map[string]string{"*": "no-cache"},
+					},
+				},
+			},
+		},
+		{
+			name: "archive source",
+			settings: Settings{
+				Target:   "site",
+				Mappings: `[{"source": "site.tar.gz", "options": {"source_archive": true}}]`,
+			},
+			want: []*mapping{{
+				source:  "/wd/site.tar.gz",
+				target:  "site",
+				archive: true,
+			}},
+		},
+		{
+			name:     "error on unknown option",
+			settings: Settings{Mappings: `[{"source": "docs", "options": {"cache": "no-cache"}}]`},
+			wantErr:  ErrInvalidMappings,
+		},
+		{
+			name:     "error on missing source",
+			settings: Settings{Mappings: `[{"target": "docs"}]`},
+			wantErr:  ErrInvalidMappings,
+		},
+		{
+			name:     "error on target outside of the target",
+			settings: Settings{Mappings: `[{"source": "docs", "target": "docs/../../other"}]`},
+			wantErr:  ErrInvalidMappings,
+		},
+		{
+			name:     "error on invalid expires",
+			settings: Settings{Mappings: `[{"source": "docs", "options": {"expires": "tomorrow"}}]`},
+			wantErr:  aws.ErrInvalidExpires,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: &tt.settings}
+
+			got, err := p.resolveMappings("/wd")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSourceFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(dir, "dist", "v1"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "dist", "app.zip"), []byte("app"), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "dist", "app.zip"+sidecarSuffix), []byte("{}"), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "dist", "v1", "app.zip"), []byte("app"), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "dist", "notes.txt"), []byte("notes"), 0o600)
+	_ = os.MkdirAll(filepath.Join(dir, "empty"), 0o755)
+
+	tests := []struct {
+		name    string
+		source  string
+		archive bool
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "directory",
+			source: filepath.Join(dir, "dist"),
+			want:   []string{"app.zip", "notes.txt", "v1/app.zip"},
+		},
+		{
+			name:   "single file",
+			source: filepath.Join(dir, "dist", "notes.txt"),
+			want:   []string{"notes.txt"},
+		},
+		{
+			name:   "single archive file",
+			source: filepath.Join(dir, "dist", "app.zip"),
+			want:   []string{"app.zip"},
+		},
+		{
+			name:    "error on archive source without archive",
+			source:  filepath.Join(dir, "dist"),
+			archive: true,
+			wantErr: ErrNoArchiveSource,
+		},
+		{
+			name:   "glob",
+			source: filepath.Join(dir, "dist", "*.zip"),
+			want:   []string{"app.zip"},
+		},
+		{
+			name:   "glob with directories",
+			source: filepath.Join(dir, "*", "v1"),
+			want:   []string{"dist/v1/app.zip"},
+		},
+		{
+			name:    "error on empty directory",
+			source:  filepath.Join(dir, "empty"),
+			wantErr: ErrEmptySourceDirectory,
+		},
+		{
+			name:    "error on glob without matches",
+			source:  filepath.Join(dir, "dist", "*.tar"),
+			wantErr: ErrEmptySourceDirectory,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Plugin{Settings: &Settings{}}
+
+			files, err := p.sourceFiles(tt.source, tt.archive)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			got := make([]string, 0, len(files))
+			for _, file := range files {
+				got = append(got, file.rel)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package plugin
+
+import "github.com/thegeeklab/wp-s3-action/aws"
+
+// contentIndex maps content hashes to existing object keys below the target. The hashes are taken
+// from the manifest of the last sync or from the ETags of the listing, which are only comparable
+// without a checksum algorithm. Objects too large for a single copy request are left out.
+func (p *Plugin) contentIndex(
+	client *aws.Client, previous *aws.Manifest, candidates map[string]aws.S3Object,
+) map[string]string {
+	hashes := make(map[string]string)
+
+	switch {
+	case previous != nil:
+		for key, obj := range previous.Objects {
+			if obj.RedirectLocation == "" {
+				hashes[key] = obj.Hash
+			}
+		}
+	case client.S3.ChecksumAlgorithm == "":
+		for key, obj := range candidates {
+			hashes[key] = obj.ETag
+		}
+	}
+
+	index := make(map[string]string, len(hashes))
+
+	for key, hash := range hashes {
+		// Objects removed outside of the sync may still be recorded in the manifest.
+		obj, ok := candidates[key]
+		if hash == "" || !ok || obj.Size > aws.MaxCopySize || p.generatedKey(key) {
+			continue
+		}
+
+		// The smallest key is used for identical objects to keep the jobs stable.
+		if current, ok := index[hash]; !ok || key < current {
+			index[hash] = key
+		}
+	}
+
+	return index
+}
+
+// assignCopySources sets the source of uploads to new keys whose content already exists under
+// another key. The returned keys are used as copy sources and must only be deleted after the copy.
+func (p *Plugin) assignCopySources(
+	client *aws.Client, previous *aws.Manifest, remote []aws.S3Object,
+) (map[string]bool, error) {
+	sources := make(map[string]bool)
+
+	if !p.Settings.DetectMoves {
+		return sources, nil
+	}
+
+	existing := make(map[string]bool, len(remote))
+	candidates := make(map[string]aws.S3Object, len(remote))
+
+	for _, obj := range remote {
+		existing[obj.Key] = true
+		candidates[obj.Key] = obj
+	}
+
+	// Keys that are uploaded in the same run may change while they are copied.
+	for _, job := range p.Settings.Jobs {
+		if job.action == "upload" {
+			delete(candidates, job.remote)
+		}
+	}
+
+	index := p.contentIndex(client, previous, candidates)
+	if len(index) == 0 {
+		return sources, nil
+	}
+
+	// Only files with the size of a copy source are hashed.
+	sizes := make(map[int64]bool, len(index))
+	for _, key := range index {
+		sizes[candidates[key].Size] = true
+	}
+
+	for i := range p.Settings.Jobs {
+		job := &p.Settings.Jobs[i]
+		if job.action != "upload" || existing[job.remote] {
+			continue
+		}
+
+		info, err := job.stat()
+		if err != nil {
+			return nil, err
+		}
+
+		if !sizes[info.Size()] {
+			continue
+		}
+
+		hash, err := client.S3.FileChecksum(job.local, job.opener)
+		if err != nil {
+			return nil, err
+		}
+
+		if source, ok := index[hash]; ok {
+			job.source = source
+			sources[source] = true
+		}
+	}
+
+	return sources, nil
+}
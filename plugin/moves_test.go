@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+func TestAssignCopySources(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	moved := filepath.Join(dir, "new", "moved.txt")
+	changed := filepath.Join(dir, "new", "changed.txt")
+
+	_ = os.MkdirAll(filepath.Dir(moved), 0o755)
+	_ = os.WriteFile(moved, []byte("hello"), 0o600)
+	_ = os.WriteFile(changed, []byte("changed"), 0o600)
+
+	previous := aws.NewManifest()
+	previous.Set("site/old/moved.txt", aws.ManifestObject{Hash: "5d41402abc4b2a76b9719d911017c592"})
+	previous.Set("site/old/missing.txt", aws.ManifestObject{Hash: "5d41402abc4b2a76b9719d911017c592"})
+	previous.Set("site/old/changed.txt", aws.ManifestObject{Hash: "0"})
+
+	p := &Plugin{Settings: &Settings{
+		Target:      "site",
+		DetectMoves: true,
+		Jobs: []Job{
+			{local: moved, remote: "site/new/moved.txt", action: "upload"},
+			{local: changed, remote: "site/new/changed.txt", action: "upload"},
+		},
+	}}
+
+	// Only site/old/moved.txt still exists, site/old/missing.txt was removed outside of the sync.
+	sources, err := p.assignCopySources(&aws.Client{S3: &aws.S3{}}, previous, []aws.S3Object{
+		{Key: "site/old/moved.txt", Size: 5},
+		{Key: "site/old/changed.txt", Size: 7},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"site/old/moved.txt": true}, sources)
+	assert.Equal(t, "site/old/moved.txt", p.Settings.Jobs[0].source)
+	assert.Empty(t, p.Settings.Jobs[1].source)
+}
+
+func TestAssignCopySources_UploadedSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	renamed := filepath.Join(dir, "b.js")
+	replaced := filepath.Join(dir, "a.js")
+
+	_ = os.WriteFile(renamed, []byte("hello"), 0o600)
+	_ = os.WriteFile(replaced, []byte("new content"), 0o600)
+
+	previous := aws.NewManifest()
+	previous.Set("site/a.js", aws.ManifestObject{Hash: "5d41402abc4b2a76b9719d911017c592"})
+
+	p := &Plugin{Settings: &Settings{
+		Target:      "site",
+		DetectMoves: true,
+		Jobs: []Job{
+			{local: renamed, remote: "site/b.js", action: "upload"},
+			{local: replaced, remote: "site/a.js", action: "upload"},
+		},
+	}}
+
+	// site/a.js is overwritten in the same run and must not be copied to site/b.js.
+	sources, err := p.assignCopySources(&aws.Client{S3: &aws.S3{}}, previous, []aws.S3Object{{Key: "site/a.js", Size: 5}})
+
+	assert.NoError(t, err)
+	assert.Empty(t, sources)
+	assert.Empty(t, p.Settings.Jobs[0].source)
+}
+
+func TestAssignCopySources_Listing(t *testing.T) {
+	t.Parallel()
+
+	moved := filepath.Join(t.TempDir(), "moved.txt")
+	_ = os.WriteFile(moved, []byte("hello"), 0o600)
+
+	p := &Plugin{Settings: &Settings{
+		Target:      "site",
+		DetectMoves: true,
+		Jobs:        []Job{{local: moved, remote: "site/new/moved.txt", action: "upload"}},
+	}}
+
+	// Without a manifest the ETags of the listing are used.
+	sources, err := p.assignCopySources(&aws.Client{S3: &aws.S3{}}, nil, []aws.S3Object{
+		{Key: "site/old/moved.txt", ETag: "5d41402abc4b2a76b9719d911017c592", Size: 5},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"site/old/moved.txt": true}, sources)
+	assert.Equal(t, "site/old/moved.txt", p.Settings.Jobs[0].source)
+
+	// Objects too large for a single copy request are left out.
+	large := aws.S3Object{Key: "site/old/large.bin", ETag: "5d41402abc4b2a76b9719d911017c592", Size: aws.MaxCopySize + 1}
+	index := p.contentIndex(&aws.Client{S3: &aws.S3{}}, nil, map[string]aws.S3Object{large.Key: large})
+	assert.Empty(t, index)
+}
@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+var (
+	ErrObjectLockRetentionRequired = errors.New("object lock mode requires a retention")
+	ErrObjectLockModeRequired      = errors.New("object lock retention requires a mode")
+)
+
+// validateObjectLock ensures that the lock mode and retention are only set together.
+func (p *Plugin) validateObjectLock() error {
+	if p.Settings.ObjectLockMode != "" && p.Settings.ObjectLockRetention == "" {
+		return ErrObjectLockRetentionRequired
+	}
+
+	if p.Settings.ObjectLockRetention != "" && p.Settings.ObjectLockMode == "" {
+		return ErrObjectLockModeRequired
+	}
+
+	for _, pattern := range p.Settings.ObjectLockFiles {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid object lock pattern '%s': %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// objectLock returns the lock of uploaded files with a retention starting at the given time.
+// Nil is returned if files are not locked.
+func (p *Plugin) objectLock(now time.Time) (*aws.ObjectLock, error) {
+	lock := &aws.ObjectLock{LegalHold: p.Settings.ObjectLockLegalHold}
+
+	if p.Settings.ObjectLockMode != "" {
+		if err := lock.Mode.Set(p.Settings.ObjectLockMode); err != nil {
+			return nil, err
+		}
+
+		retention, err := aws.ParseRetention(p.Settings.ObjectLockRetention)
+		if err != nil {
+			return nil, err
+		}
+
+		lock.RetainUntil = now.Add(retention).UTC()
+	}
+
+	if lock.Mode == "" && !lock.LegalHold {
+		return nil, nil //nolint:nilnil
+	}
+
+	return lock, nil
+}
+
+// checksumAlgorithm returns the configured checksum algorithm. Uploads with Object Lock require an
+// integrity checksum, which is not sent by default, so CRC32C is used if no algorithm is configured.
+func (p *Plugin) checksumAlgorithm() (aws.ChecksumAlgorithm, error) {
+	var alg aws.ChecksumAlgorithm
+
+	if err := alg.Set(p.Settings.ChecksumAlgorithm); err != nil {
+		return "", err
+	}
+
+	if alg == "" && (p.Settings.ObjectLockMode != "" || p.Settings.ObjectLockLegalHold) {
+		alg = aws.ChecksumAlgorithmCRC32C
+	}
+
+	return alg, nil
+}
+
+// objectLocked reports whether the path of a file relative to its source matches the object lock patterns.
+func (p *Plugin) objectLocked(rel string) bool {
+	if len(p.Settings.ObjectLockFiles) == 0 {
+		return true
+	}
+
+	for _, pattern := range p.Settings.ObjectLockFiles {
+		if match, _ := filepath.Match(pattern, rel); match {
+			return true
+		}
+	}
+
+	return false
+}
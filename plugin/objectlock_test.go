@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+func TestObjectLock(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		settings Settings
+		want     *aws.ObjectLock
+		wantErr  error
+	}{
+		{
+			name: "no lock",
+		},
+		{
+			name:     "retention",
+			settings: Settings{ObjectLockMode: "compliance", ObjectLockRetention: "7y"},
+			want: &aws.ObjectLock{
+				Mode:        aws.ObjectLockCompliance,
+				RetainUntil: now.Add(7 * 365 * 24 * time.Hour),
+			},
+		},
+		{
+			name:     "legal hold only",
+			settings: Settings{ObjectLockLegalHold: true},
+			want:     &aws.ObjectLock{LegalHold: true},
+		},
+		{
+			name:     "error on mode without retention",
+			settings: Settings{ObjectLockMode: "GOVERNANCE"},
+			wantErr:  ErrObjectLockRetentionRequired,
+		},
+		{
+			name:     "error on retention without mode",
+			settings: Settings{ObjectLockRetention: "30d"},
+			wantErr:  ErrObjectLockModeRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Plugin{Settings: &tt.settings}
+
+			err := p.validateObjectLock()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			got, err := p.objectLock(now)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestObjectLocked(t *testing.T) {
+	t.Parallel()
+
+	p := &Plugin{Settings: &Settings{ObjectLockFiles: []string{"audit/*.json"}}}
+
+	assert.True(t, p.objectLocked("audit/report.json"))
+	assert.False(t, p.objectLocked("index.html"))
+	assert.False(t, p.objectLocked("other/audit/report.json"))
+	assert.True(t, (&Plugin{Settings: &Settings{}}).objectLocked("index.html"))
+}
+
+func TestChecksumAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings Settings
+		want     aws.ChecksumAlgorithm
+	}{
+		{
+			name: "no checksum algorithm",
+			want: "",
+		},
+		{
+			name:     "default for object lock",
+			settings: Settings{ObjectLockMode: "GOVERNANCE", ObjectLockRetention: "30d"},
+			want:     aws.ChecksumAlgorithmCRC32C,
+		},
+		{
+			name:     "default for legal hold",
+			settings: Settings{ObjectLockLegalHold: true},
+			want:     aws.ChecksumAlgorithmCRC32C,
+		},
+		{
+			name:     "configured algorithm for object lock",
+			settings: Settings{ObjectLockLegalHold: true, ChecksumAlgorithm: "sha256"},
+			want:     aws.ChecksumAlgorithmSHA256,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Plugin{Settings: &tt.settings}
+
+			got, err := p.checksumAlgorithm()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package plugin
 
 import (
 	"fmt"
+	"io"
+	"time"
 
 	plugin_cli "github.com/thegeeklab/wp-plugin-go/v6/cli"
 	plugin_base "github.com/thegeeklab/wp-plugin-go/v6/plugin"
@@ -25,6 +27,7 @@ type Settings struct {
 	Bucket                 string
 	Region                 string
 	Source                 string
+	SourceArchive          bool
 	Target                 string
 	Delete                 bool
 	ACL                    map[string]string
@@ -40,12 +43,71 @@ type Settings struct {
 	ChecksumCalculation    string
 	Jobs                   []Job
 	MaxConcurrency         int
+	RetryMaxAttempts       int
+	RetryMaxBackoff        time.Duration
+	MaxBandwidth           string
+	MultipartPartSize      string
+	Checkpoint             string
+	Manifest               bool
+	ManifestVerify         bool
+	Compare                string
+	ChecksumAlgorithm      string
+	Verify                 string
+	ChecksumFile           string
+	ChecksumFileFormat     string
+	ChecksumFileSigningKey string
+	ReleaseVersion         string
+	ReleaseAliases         []string
+	Provenance             bool
+	ContentDisposition     map[string]string
+	ContentLanguage        map[string]string
+	Expires                map[string]string
+	WebsiteRedirect        map[string]string
+	HeadersFile            string
+	MIMETypesFile          string
+	DefaultCharset         string
+	ACLMode                string
+	ACLErrorPolicy         string
+	ObjectLockMode         string
+	ObjectLockRetention    string
+	ObjectLockLegalHold    bool
+	ObjectLockFiles        []string
+	KeepVersions           int
+	PurgeDeleteMarkers     bool
+	Restore                string
+	Lock                   bool
+	LockTimeout            time.Duration
+	LockTTL                time.Duration
+	ConditionalWrites      bool
+	DetectMoves            bool
+	Mappings               string
+
+	// mappings are the resolved source and target pairs of the sync.
+	mappings []*mapping
+	// archives are the source archives that are read during the sync, they are closed after the sync.
+	archives []io.Closer
+	// root is the target before it is moved to the release version.
+	root string
 }
 
 type Job struct {
 	local  string
 	remote string
 	action string
+	// source is the object key copied by copy jobs.
+	source string
+	// deferred jobs run after all other jobs, e.g. updates of release aliases and deletes of copy sources.
+	deferred bool
+	// headers are the options of the sidecar file of an upload.
+	headers map[string]string
+	// version is the object version copied by restore jobs.
+	version string
+	// mapping is the mapping of an upload.
+	mapping *mapping
+	// opener reads the local file of an upload from an archive.
+	opener aws.OpenFunc
+	// digest is the SHA-256 hash of an archive entry.
+	digest string
 }
 
 type Result struct {
@@ -141,6 +203,13 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			Destination: &settings.Source,
 			Category:    category,
 		},
+		&cli.BoolFlag{
+			Name:        "source-archive",
+			Usage:       "sync the entries of the tar or zip archive source instead of the archive",
+			Sources:     cli.EnvVars("PLUGIN_SOURCE_ARCHIVE"),
+			Destination: &settings.SourceArchive,
+			Category:    category,
+		},
 		&cli.StringFlag{
 			Name:        "target",
 			Usage:       "upload target path",
@@ -156,6 +225,71 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			Destination: &settings.Delete,
 			Category:    category,
 		},
+		&cli.IntFlag{
+			Name:        "keep-versions",
+			Usage:       "number of noncurrent versions kept for files changed by the sync, -1 keeps all versions",
+			Value:       -1,
+			Sources:     cli.EnvVars("PLUGIN_KEEP_VERSIONS"),
+			Destination: &settings.KeepVersions,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "purge-delete-markers",
+			Usage:       "remove delete markers below the target that no longer hide a version",
+			Sources:     cli.EnvVars("PLUGIN_PURGE_DELETE_MARKERS"),
+			Destination: &settings.PurgeDeleteMarkers,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "restore",
+			Usage:       "restore the target to the object versions current at the given RFC 3339 time instead of syncing",
+			Sources:     cli.EnvVars("PLUGIN_RESTORE"),
+			Destination: &settings.Restore,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "lock",
+			Usage:       "hold a lock object below the target to prevent concurrent syncs",
+			Sources:     cli.EnvVars("PLUGIN_LOCK"),
+			Destination: &settings.Lock,
+			Category:    category,
+		},
+		&cli.DurationFlag{
+			Name:        "lock-timeout",
+			Usage:       "time to wait for a lock held by another run, fails immediately if zero",
+			Sources:     cli.EnvVars("PLUGIN_LOCK_TIMEOUT"),
+			Destination: &settings.LockTimeout,
+			Category:    category,
+		},
+		&cli.DurationFlag{
+			Name:        "lock-ttl",
+			Usage:       "time after which a lock of an aborted run is taken over",
+			Value:       time.Hour,
+			Sources:     cli.EnvVars("PLUGIN_LOCK_TTL"),
+			Destination: &settings.LockTTL,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "conditional-writes",
+			Usage:       "fail uploads of objects that were modified concurrently",
+			Sources:     cli.EnvVars("PLUGIN_CONDITIONAL_WRITES"),
+			Destination: &settings.ConditionalWrites,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "detect-moves",
+			Usage:       "copy new files whose content already exists in the target instead of uploading them",
+			Sources:     cli.EnvVars("PLUGIN_DETECT_MOVES"),
+			Destination: &settings.DetectMoves,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "mappings",
+			Usage:       "JSON list of source and target pairs with upload options synced in one step",
+			Sources:     cli.EnvVars("PLUGIN_MAPPINGS"),
+			Destination: &settings.Mappings,
+			Category:    category,
+		},
 		&plugin_cli.StringMapFlag{
 			Name:        "acl",
 			Usage:       "access control list",
@@ -163,6 +297,77 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			Destination: &settings.ACL,
 			Category:    category,
 		},
+		&cli.StringFlag{
+			Name: "acl-mode",
+			Usage: fmt.Sprintf(
+				"ACL handling (%s, %s or %s)", aws.ACLModeEnabled, aws.ACLModeDisabled, aws.ACLModeAuto,
+			),
+			Sources:     cli.EnvVars("PLUGIN_ACL_MODE"),
+			Destination: &settings.ACLMode,
+			Value:       string(aws.ACLModeEnabled),
+			Validator: func(s string) error {
+				var mode aws.ACLMode
+
+				return mode.Set(s)
+			},
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name: "acl-error-policy",
+			Usage: fmt.Sprintf(
+				"handling of errors reading object ACLs (%s, %s or %s)",
+				aws.ACLErrorPolicyIgnore, aws.ACLErrorPolicyWarn, aws.ACLErrorPolicyFail,
+			),
+			Sources:     cli.EnvVars("PLUGIN_ACL_ERROR_POLICY"),
+			Destination: &settings.ACLErrorPolicy,
+			Value:       string(aws.ACLErrorPolicyWarn),
+			Validator: func(s string) error {
+				var policy aws.ACLErrorPolicy
+
+				return policy.Set(s)
+			},
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name: "object-lock-mode",
+			Usage: fmt.Sprintf(
+				"object lock mode of uploaded files (%s or %s)", aws.ObjectLockGovernance, aws.ObjectLockCompliance,
+			),
+			Sources:     cli.EnvVars("PLUGIN_OBJECT_LOCK_MODE"),
+			Destination: &settings.ObjectLockMode,
+			Validator: func(s string) error {
+				var mode aws.ObjectLockMode
+
+				return mode.Set(s)
+			},
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:        "object-lock-retention",
+			Usage:       "retention period of locked files, e.g. 7y, 30d or 12h",
+			Sources:     cli.EnvVars("PLUGIN_OBJECT_LOCK_RETENTION"),
+			Destination: &settings.ObjectLockRetention,
+			Validator: func(s string) error {
+				_, err := aws.ParseRetention(s)
+
+				return err
+			},
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:        "object-lock-legal-hold",
+			Usage:       "place a legal hold on uploaded files",
+			Sources:     cli.EnvVars("PLUGIN_OBJECT_LOCK_LEGAL_HOLD"),
+			Destination: &settings.ObjectLockLegalHold,
+			Category:    category,
+		},
+		&cli.StringSliceFlag{
+			Name:        "object-lock-files",
+			Usage:       "patterns of files to lock relative to the source, all files are locked if empty",
+			Sources:     cli.EnvVars("PLUGIN_OBJECT_LOCK_FILES"),
+			Destination: &settings.ObjectLockFiles,
+			Category:    category,
+		},
 		&plugin_cli.StringMapFlag{
 			Name:        "content-type",
 			Usage:       "content-type settings for uploads",
@@ -170,6 +375,21 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			Destination: &settings.ContentType,
 			Category:    category,
 		},
+		&cli.StringFlag{
+			Name:        "mime-types-file",
+			Usage:       "file with additional content types per file extension in the format of /etc/mime.types",
+			Sources:     cli.EnvVars("PLUGIN_MIME_TYPES_FILE"),
+			Destination: &settings.MIMETypesFile,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "default-charset",
+			Usage:       "charset added to text content types without an explicit charset",
+			Sources:     cli.EnvVars("PLUGIN_DEFAULT_CHARSET"),
+			Destination: &settings.DefaultCharset,
+			Value:       "utf-8",
+			Category:    category,
+		},
 		&plugin_cli.StringMapFlag{
 			Name:        "content-encoding",
 			Usage:       "content-encoding settings for uploads",
@@ -184,6 +404,41 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			Destination: &settings.CacheControl,
 			Category:    category,
 		},
+		&plugin_cli.StringMapFlag{
+			Name:        "content-disposition",
+			Usage:       "content-disposition settings for uploads",
+			Sources:     cli.EnvVars("PLUGIN_CONTENT_DISPOSITION"),
+			Destination: &settings.ContentDisposition,
+			Category:    category,
+		},
+		&plugin_cli.StringMapFlag{
+			Name:        "content-language",
+			Usage:       "content-language settings for uploads",
+			Sources:     cli.EnvVars("PLUGIN_CONTENT_LANGUAGE"),
+			Destination: &settings.ContentLanguage,
+			Category:    category,
+		},
+		&plugin_cli.StringMapFlag{
+			Name:        "expires",
+			Usage:       "expires settings for uploads as RFC 3339 or HTTP date",
+			Sources:     cli.EnvVars("PLUGIN_EXPIRES"),
+			Destination: &settings.Expires,
+			Category:    category,
+		},
+		&plugin_cli.StringMapFlag{
+			Name:        "website-redirect",
+			Usage:       "website redirect locations for uploads",
+			Sources:     cli.EnvVars("PLUGIN_WEBSITE_REDIRECT"),
+			Destination: &settings.WebsiteRedirect,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "headers-file",
+			Usage:       "headers file relative to the source that assigns headers to path patterns, e.g. _headers",
+			Sources:     cli.EnvVars("PLUGIN_HEADERS_FILE"),
+			Destination: &settings.HeadersFile,
+			Category:    category,
+		},
 		&plugin_cli.DeepStringMapFlag{
 			Name:        "metadata",
 			Usage:       "additional metadata for uploads",
@@ -220,6 +475,82 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			Destination: &settings.MaxConcurrency,
 			Category:    category,
 		},
+		&cli.IntFlag{
+			Name:        "retry-max-attempts",
+			Usage:       "maximum number of attempts for a failed request",
+			Value:       5,
+			Sources:     cli.EnvVars("PLUGIN_RETRY_MAX_ATTEMPTS"),
+			Destination: &settings.RetryMaxAttempts,
+			Category:    category,
+		},
+		&cli.DurationFlag{
+			Name:        "retry-max-backoff",
+			Usage:       "maximum backoff duration between retries of a failed request",
+			Value:       20 * time.Second,
+			Sources:     cli.EnvVars("PLUGIN_RETRY_MAX_BACKOFF"),
+			Destination: &settings.RetryMaxBackoff,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "max-bandwidth",
+			Usage:       "maximum bandwidth for all transfers combined, e.g. 20MiB/s",
+			Sources:     cli.EnvVars("PLUGIN_MAX_BANDWIDTH"),
+			Destination: &settings.MaxBandwidth,
+			Validator: func(s string) error {
+				_, err := aws.ParseBandwidth(s)
+
+				return err
+			},
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:        "multipart-part-size",
+			Usage:       "upload files larger than the given size in parts of this size, e.g. 64MiB",
+			Sources:     cli.EnvVars("PLUGIN_MULTIPART_PART_SIZE"),
+			Destination: &settings.MultipartPartSize,
+			Validator: func(s string) error {
+				_, err := aws.ParsePartSize(s)
+
+				return err
+			},
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:        "checkpoint",
+			Usage:       "path to a checkpoint file to resume interrupted syncs",
+			Sources:     cli.EnvVars("PLUGIN_CHECKPOINT"),
+			Destination: &settings.Checkpoint,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "manifest",
+			Usage:       "store a deploy manifest in the bucket and only update objects that changed since the last sync",
+			Sources:     cli.EnvVars("PLUGIN_MANIFEST"),
+			Destination: &settings.Manifest,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "manifest-verify",
+			Usage:       "ignore the stored deploy manifest and check every object with a HEAD request",
+			Sources:     cli.EnvVars("PLUGIN_MANIFEST_VERIFY"),
+			Destination: &settings.ManifestVerify,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name: "compare",
+			Usage: fmt.Sprintf(
+				"change detection mode (%s, %s or %s)", aws.CompareChecksum, aws.CompareSize, aws.CompareSizeAndMtime,
+			),
+			Sources:     cli.EnvVars("PLUGIN_COMPARE"),
+			Destination: &settings.Compare,
+			Value:       string(aws.CompareChecksum),
+			Validator: func(s string) error {
+				var mode aws.CompareMode
+
+				return mode.Set(s)
+			},
+			Category: category,
+		},
 		&cli.StringFlag{
 			Name:        "checksum-calculation",
 			Usage:       fmt.Sprintf("checksum calculation mode (%s or %s)", aws.ChecksumSupported, aws.ChecksumRequired),
@@ -233,6 +564,81 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			},
 			Category: category,
 		},
+		&cli.StringFlag{
+			Name: "checksum-algorithm",
+			Usage: fmt.Sprintf(
+				"additional checksum algorithm for upload integrity and change detection (%s, %s or %s)",
+				aws.ChecksumAlgorithmCRC32C, aws.ChecksumAlgorithmCRC64NVME, aws.ChecksumAlgorithmSHA256,
+			),
+			Sources:     cli.EnvVars("PLUGIN_CHECKSUM_ALGORITHM"),
+			Destination: &settings.ChecksumAlgorithm,
+			Validator: func(s string) error {
+				var alg aws.ChecksumAlgorithm
+
+				return alg.Set(s)
+			},
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name: "verify",
+			Usage: fmt.Sprintf(
+				"verify uploaded objects against the local files (%s or %s)", aws.VerifyChecksum, aws.VerifyDownload,
+			),
+			Sources:     cli.EnvVars("PLUGIN_VERIFY"),
+			Destination: &settings.Verify,
+			Validator: func(s string) error {
+				var mode aws.VerifyMode
+
+				return mode.Set(s)
+			},
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:        "checksum-file",
+			Usage:       "name of a checksum file with the SHA-256 hashes of all uploaded files, e.g. SHA256SUMS",
+			Sources:     cli.EnvVars("PLUGIN_CHECKSUM_FILE"),
+			Destination: &settings.ChecksumFile,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name: "checksum-file-format",
+			Usage: fmt.Sprintf(
+				"format of the checksum file (%s or %s)", ChecksumFileFormatGNU, ChecksumFileFormatBSD,
+			),
+			Sources:     cli.EnvVars("PLUGIN_CHECKSUM_FILE_FORMAT"),
+			Destination: &settings.ChecksumFileFormat,
+			Value:       ChecksumFileFormatGNU,
+			Validator:   validateChecksumFileFormat,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "checksum-file-signing-key",
+			Usage:       "unencrypted minisign secret key or base64 encoded ed25519 key to sign the checksum file",
+			Sources:     cli.EnvVars("PLUGIN_CHECKSUM_FILE_SIGNING_KEY"),
+			Destination: &settings.ChecksumFileSigningKey,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "release-version",
+			Usage:       "upload to a version prefix below the target, templates like {{ tag }} are expanded",
+			Sources:     cli.EnvVars("PLUGIN_RELEASE_VERSION"),
+			Destination: &settings.ReleaseVersion,
+			Category:    category,
+		},
+		&cli.StringSliceFlag{
+			Name:        "release-aliases",
+			Usage:       "alias prefixes below the target that mirror the release version, e.g. latest",
+			Sources:     cli.EnvVars("PLUGIN_RELEASE_ALIASES"),
+			Destination: &settings.ReleaseAliases,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "provenance",
+			Usage:       "add the commit, pipeline number, repository and deploy time to the metadata of all objects",
+			Sources:     cli.EnvVars("PLUGIN_PROVENANCE"),
+			Destination: &settings.Provenance,
+			Category:    category,
+		},
 		&cli.BoolFlag{
 			Name:        "allow-empty-source",
 			Usage:       "allow empty source directory",
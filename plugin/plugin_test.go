@@ -285,3 +285,97 @@ func TestChecksumCalculationFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		envs    map[string]string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "default value",
+			envs: map[string]string{},
+			want: string(aws.CompareChecksum),
+		},
+		{
+			name: "set to size-and-mtime",
+			envs: map[string]string{
+				"PLUGIN_COMPARE": "size-and-mtime",
+			},
+			want: string(aws.CompareSizeAndMtime),
+		},
+		{
+			name: "invalid value causes error",
+			envs: map[string]string{
+				"PLUGIN_COMPARE": "invalid",
+			},
+			wantErr: aws.ErrInvalidCompareMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.envs {
+				t.Setenv(key, value)
+			}
+
+			got, err := setupPluginTest(t)
+
+			if tt.wantErr != nil {
+				assert.ErrorAs(t, err, &tt.wantErr)
+
+				return
+			}
+
+			assert.Equal(t, tt.want, got.Settings.Compare)
+		})
+	}
+}
+
+func TestChecksumAlgorithmFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		envs    map[string]string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "default value",
+			envs: map[string]string{},
+			want: "",
+		},
+		{
+			name: "set to crc64nvme",
+			envs: map[string]string{
+				"PLUGIN_CHECKSUM_ALGORITHM": "crc64nvme",
+			},
+			want: string(aws.ChecksumAlgorithmCRC64NVME),
+		},
+		{
+			name: "invalid value causes error",
+			envs: map[string]string{
+				"PLUGIN_CHECKSUM_ALGORITHM": "md5",
+			},
+			wantErr: aws.ErrInvalidChecksumAlgorithm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.envs {
+				t.Setenv(key, value)
+			}
+
+			got, err := setupPluginTest(t)
+
+			if tt.wantErr != nil {
+				assert.ErrorAs(t, err, &tt.wantErr)
+
+				return
+			}
+
+			assert.Equal(t, tt.want, got.Settings.ChecksumAlgorithm)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package plugin
+
+import (
+	"os"
+	"time"
+
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+// provenanceMetadata returns the metadata that records the commit, pipeline and repository
+// from the Woodpecker environment. Unset variables are omitted.
+func provenanceMetadata(now time.Time) map[string]string {
+	metadata := map[string]string{
+		aws.MetadataDeployedAt: now.UTC().Format(time.RFC3339),
+	}
+
+	for key, name := range map[string]string{
+		aws.MetadataCommit:         "CI_COMMIT_SHA",
+		aws.MetadataPipelineNumber: "CI_PIPELINE_NUMBER",
+		aws.MetadataRepo:           "CI_REPO",
+	} {
+		if value := os.Getenv(name); value != "" {
+			metadata[key] = value
+		}
+	}
+
+	return metadata
+}
@@ -0,0 +1,23 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+func TestProvenanceMetadata(t *testing.T) {
+	t.Setenv("CI_COMMIT_SHA", "abc")
+	t.Setenv("CI_PIPELINE_NUMBER", "42")
+	t.Setenv("CI_REPO", "")
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	assert.Equal(t, map[string]string{
+		aws.MetadataDeployedAt:     "2026-01-02T02:04:05Z",
+		aws.MetadataCommit:         "abc",
+		aws.MetadataPipelineNumber: "42",
+	}, provenanceMetadata(now))
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+// createRestoreJobs creates the jobs that restore the target to the object versions that were
+// current at the restore time. Keys that did not exist at that time are deleted.
+func (p *Plugin) createRestoreJobs(ctx context.Context, client *aws.Client) error {
+	at, err := time.Parse(time.RFC3339, p.Settings.Restore)
+	if err != nil {
+		return err
+	}
+
+	plan, err := client.S3.PlanRestore(ctx, keyPrefix(p.Settings.Target), at)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf(
+		"Restoring '%s' to %s: %d objects restored, %d objects deleted",
+		p.Settings.Target, at.Format(time.RFC3339), len(plan.Versions), len(plan.Deleted),
+	)
+
+	keys := make([]string, 0, len(plan.Versions))
+	for key := range plan.Versions {
+		if key != p.lockKey() {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	// The local path is only used to match the ACL patterns.
+	for _, key := range keys {
+		p.Settings.Jobs = append(p.Settings.Jobs, Job{
+			local:   filepath.Join(p.Settings.Source, strings.TrimPrefix(key, p.Settings.Target+"/")),
+			remote:  key,
+			action:  "restore",
+			version: plan.Versions[key],
+		})
+	}
+
+	for _, key := range plan.Deleted {
+		// The lock of the running restore did not exist at the restore time.
+		if key == p.lockKey() {
+			continue
+		}
+
+		p.Settings.Jobs = append(p.Settings.Jobs, Job{
+			local:  "",
+			remote: key,
+			action: "delete",
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const restoreVersions = `<?xml version="1.0" encoding="UTF-8"?>
+<ListVersionsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>bucket</Name>
+  <IsTruncated>false</IsTruncated>
+  <Version>
+    <Key>site/index.html</Key><VersionId>v1</VersionId><IsLatest>false</IsLatest>
+    <LastModified>2024-01-01T00:00:00.000Z</LastModified>
+  </Version>
+  <Version>
+    <Key>site/index.html</Key><VersionId>v2</VersionId><IsLatest>true</IsLatest>
+    <LastModified>2024-03-01T00:00:00.000Z</LastModified>
+  </Version>
+  <Version>
+    <Key>site/new.html</Key><VersionId>v3</VersionId><IsLatest>true</IsLatest>
+    <LastModified>2024-03-01T00:00:00.000Z</LastModified>
+  </Version>
+  <Version>
+    <Key>site/.s3-action-lock.json</Key><VersionId>v4</VersionId><IsLatest>true</IsLatest>
+    <LastModified>2024-03-01T00:00:00.000Z</LastModified>
+  </Version>
+</ListVersionsResult>`
+
+func TestCreateRestoreJobs(t *testing.T) {
+	t.Parallel()
+
+	var prefix string
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		prefix = r.URL.Query().Get("prefix")
+
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(restoreVersions))
+	})
+
+	p := &Plugin{Settings: &Settings{
+		Source:  "/src",
+		Target:  "site",
+		root:    "site",
+		Restore: "2024-02-01T00:00:00Z",
+	}}
+
+	assert.NoError(t, p.createRestoreJobs(t.Context(), client))
+
+	// Sibling prefixes like site-staging must not be restored.
+	assert.Equal(t, "site/", prefix)
+	assert.Equal(t, []Job{
+		{local: "/src/index.html", remote: "site/index.html", action: "restore", version: "v1"},
+		{local: "", remote: "site/new.html", action: "delete"},
+	}, p.Settings.Jobs)
+}
@@ -0,0 +1,79 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+// sidecarSuffix is appended to the name of a file to get its sidecar file.
+const sidecarSuffix = ".s3meta.json"
+
+// sidecar holds the upload options of a single file. Set options take precedence over the
+// pattern settings and the headers file.
+type sidecar struct {
+	ACL                string            `json:"acl"`
+	ContentType        string            `json:"contentType"`
+	ContentEncoding    string            `json:"contentEncoding"`
+	CacheControl       string            `json:"cacheControl"`
+	ContentDisposition string            `json:"contentDisposition"`
+	ContentLanguage    string            `json:"contentLanguage"`
+	Expires            string            `json:"expires"`
+	WebsiteRedirect    string            `json:"websiteRedirect"`
+	Metadata           map[string]string `json:"metadata"`
+}
+
+// loadSidecar reads the sidecar file and returns its options as HTTP headers.
+func loadSidecar(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sidecar file: %w", err)
+	}
+
+	return parseSidecar(path, data)
+}
+
+// parseSidecar parses the content of a sidecar file and returns its options as HTTP headers.
+func parseSidecar(path string, data []byte) (map[string]string, error) {
+	var meta sidecar
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&meta); err != nil {
+		return nil, fmt.Errorf("failed to parse sidecar file '%s': %w", path, err)
+	}
+
+	headers := make(map[string]string)
+
+	for name, value := range map[string]string{
+		"X-Amz-Acl":           meta.ACL,
+		"Content-Type":        meta.ContentType,
+		"Content-Encoding":    meta.ContentEncoding,
+		"Cache-Control":       meta.CacheControl,
+		"Content-Disposition": meta.ContentDisposition,
+		"Content-Language":    meta.ContentLanguage,
+		"Expires":             meta.Expires,
+
+		"X-Amz-Website-Redirect-Location": meta.WebsiteRedirect,
+	} {
+		if value != "" {
+			headers[name] = value
+		}
+	}
+
+	for key, value := range meta.Metadata {
+		headers["X-Amz-Meta-"+key] = value
+	}
+
+	for name, value := range headers {
+		if err := aws.ValidateHeader(name, value); err != nil {
+			return nil, fmt.Errorf("invalid sidecar file '%s': %w", path, err)
+		}
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+func TestLoadSidecar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr error
+	}{
+		{
+			name:    "map options to headers",
+			content: `{"acl":"public-read","cacheControl":"max-age=60","websiteRedirect":"/new/","metadata":{"owner":"web"}}`,
+			want: map[string]string{
+				"X-Amz-Acl":                       "public-read",
+				"Cache-Control":                   "max-age=60",
+				"X-Amz-Website-Redirect-Location": "/new/",
+				"X-Amz-Meta-owner":                "web",
+			},
+		},
+		{
+			name:    "error on invalid expires",
+			content: `{"expires":"tomorrow"}`,
+			wantErr: aws.ErrInvalidExpires,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "robots.txt"+sidecarSuffix)
+			_ = os.WriteFile(path, []byte(tt.content), 0o600)
+
+			got, err := loadSidecar(path)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("error on unknown option", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "robots.txt"+sidecarSuffix)
+		_ = os.WriteFile(path, []byte(`{"cache":"max-age=60"}`), 0o600)
+
+		_, err := loadSidecar(path)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,175 @@
+package plugin
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ChecksumFileFormatGNU = "gnu"
+	ChecksumFileFormatBSD = "bsd"
+
+	// signatureSuffix is appended to the checksum file name for the detached signature.
+	signatureSuffix = ".minisig"
+)
+
+// Layout of a minisign secret key, see https://jedisct1.github.io/minisign/.
+const (
+	minisignKeyLength   = 158
+	minisignKDFOffset   = 2
+	minisignKeyIDOffset = 54
+	minisignSKOffset    = 62
+	minisignKeyIDLength = 8
+)
+
+var (
+	ErrInvalidChecksumFileFormat = errors.New("invalid checksum file format")
+	ErrInvalidSigningKey         = errors.New("invalid signing key")
+	ErrEncryptedSigningKey       = errors.New("encrypted signing keys are not supported")
+)
+
+// checksumFile collects the SHA-256 hashes of all uploaded files. A nil checksum file
+// disables the collection.
+type checksumFile struct {
+	mu     sync.Mutex
+	format string
+	sums   map[string]string
+}
+
+// signingKey is an ed25519 key with the key ID used in minisign signatures.
+type signingKey struct {
+	id  [minisignKeyIDLength]byte
+	key ed25519.PrivateKey
+}
+
+func validateChecksumFileFormat(format string) error {
+	switch format {
+	case ChecksumFileFormatGNU, ChecksumFileFormatBSD:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidChecksumFileFormat, format)
+	}
+}
+
+func newChecksumFile(format string) *checksumFile {
+	return &checksumFile{
+		format: format,
+		sums:   make(map[string]string),
+	}
+}
+
+// Add hashes the local file and records it with the given name.
+func (c *checksumFile) Add(name string, job Job) error {
+	if c == nil {
+		return nil
+	}
+
+	sum, err := job.hash()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.sums[name] = sum
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Bytes returns the content of the checksum file sorted by name.
+func (c *checksumFile) Bytes() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := make([]string, 0, len(c.sums))
+	for name := range c.sums {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+
+	for _, name := range names {
+		if c.format == ChecksumFileFormatBSD {
+			fmt.Fprintf(&buf, "SHA256 (%s) = %s\n", name, c.sums[name])
+
+			continue
+		}
+
+		fmt.Fprintf(&buf, "%s  %s\n", c.sums[name], name)
+	}
+
+	return buf.Bytes()
+}
+
+// parseSigningKey reads an unencrypted minisign secret key as created by `minisign -G -W`
+// or a base64 encoded ed25519 private key. Raw ed25519 keys use the first bytes of the
+// public key as key ID.
+func parseSigningKey(value string) (*signingKey, error) {
+	lines := strings.Split(strings.TrimSpace(value), "\n")
+	encoded := strings.TrimSpace(lines[len(lines)-1])
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSigningKey, err)
+	}
+
+	sk := &signingKey{}
+
+	switch len(data) {
+	case ed25519.SeedSize:
+		sk.key = ed25519.NewKeyFromSeed(data)
+	case ed25519.PrivateKeySize:
+		sk.key = ed25519.PrivateKey(data)
+	case minisignKeyLength:
+		if string(data[:minisignKDFOffset]) != "Ed" {
+			return nil, fmt.Errorf("%w: unsupported signature algorithm", ErrInvalidSigningKey)
+		}
+
+		if data[minisignKDFOffset] != 0 || data[minisignKDFOffset+1] != 0 {
+			return nil, ErrEncryptedSigningKey
+		}
+
+		copy(sk.id[:], data[minisignKeyIDOffset:minisignSKOffset])
+		sk.key = ed25519.PrivateKey(data[minisignSKOffset : minisignSKOffset+ed25519.PrivateKeySize])
+
+		return sk, nil
+	default:
+		return nil, fmt.Errorf("%w: unexpected key length %d", ErrInvalidSigningKey, len(data))
+	}
+
+	pub, _ := sk.key.Public().(ed25519.PublicKey)
+	copy(sk.id[:], pub)
+
+	return sk, nil
+}
+
+// Sign creates a detached minisign signature of the content.
+func (k *signingKey) Sign(name string, content []byte, now time.Time) []byte {
+	signature := ed25519.Sign(k.key, content)
+	trusted := fmt.Sprintf("timestamp:%d\tfile:%s", now.Unix(), name)
+
+	// The global signature covers the signature and the trusted comment.
+	global := ed25519.Sign(k.key, append(bytes.Clone(signature), trusted...))
+
+	sig := append([]byte("Ed"), k.id[:]...)
+	sig = append(sig, signature...)
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "untrusted comment: signature from wp-s3-action key %016X\n", binary.LittleEndian.Uint64(k.id[:]))
+	fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(sig))
+	fmt.Fprintf(&buf, "trusted comment: %s\n", trusted)
+	fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(global))
+
+	return buf.Bytes()
+}
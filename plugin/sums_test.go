@@ -0,0 +1,186 @@
+package plugin
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksumFile_Bytes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "b.txt"), []byte("world"), 0o600)
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "gnu format",
+			format: ChecksumFileFormatGNU,
+			want: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  a.txt\n" +
+				"486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7  dist/b.txt\n",
+		},
+		{
+			name:   "bsd format",
+			format: ChecksumFileFormatBSD,
+			want: "SHA256 (a.txt) = 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824\n" +
+				"SHA256 (dist/b.txt) = 486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sums := newChecksumFile(tt.format)
+
+			assert.NoError(t, sums.Add("dist/b.txt", Job{local: filepath.Join(dir, "b.txt")}))
+			assert.NoError(t, sums.Add("a.txt", Job{local: filepath.Join(dir, "a.txt")}))
+			assert.Equal(t, tt.want, string(sums.Bytes()))
+		})
+	}
+}
+
+func TestRunJob_ChecksumFile(t *testing.T) {
+	t.Parallel()
+
+	local := filepath.Join(t.TempDir(), "a.txt")
+	_ = os.WriteFile(local, []byte("hello"), 0o600)
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{
+			name: "name relative to release directory",
+			file: "SHA256SUMS",
+			want: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  docs/a.txt\n",
+		},
+		{
+			name: "name relative to nested checksum file",
+			file: "meta/SHA256SUMS",
+			want: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  ../docs/a.txt\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			p := &Plugin{Settings: &Settings{Target: "releases/v1", ChecksumFile: tt.file}}
+			state := &syncState{client: client, sums: newChecksumFile(ChecksumFileFormatGNU)}
+
+			err := p.runJob(t.Context(), state, Job{
+				local:   local,
+				remote:  "releases/v1/docs/a.txt",
+				action:  "upload",
+				mapping: &mapping{target: "releases/v1/docs"},
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(state.sums.Bytes()))
+		})
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	t.Parallel()
+
+	seed := make([]byte, ed25519.SeedSize)
+	key := ed25519.NewKeyFromSeed(seed)
+
+	minisign := func(kdf string) string {
+		data := make([]byte, minisignKeyLength)
+		copy(data, "Ed")
+		copy(data[minisignKDFOffset:], kdf)
+		copy(data[minisignKeyIDOffset:], "12345678")
+		copy(data[minisignSKOffset:], key)
+
+		return "untrusted comment: minisign secret key\n" + base64.StdEncoding.EncodeToString(data)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantID  string
+		wantErr error
+	}{
+		{
+			name:   "raw ed25519 seed",
+			value:  base64.StdEncoding.EncodeToString(seed),
+			wantID: string(key.Public().(ed25519.PublicKey)[:minisignKeyIDLength]),
+		},
+		{
+			name:   "unencrypted minisign key",
+			value:  minisign("\x00\x00"),
+			wantID: "12345678",
+		},
+		{
+			name:    "error on encrypted minisign key",
+			value:   minisign("Sc"),
+			wantErr: ErrEncryptedSigningKey,
+		},
+		{
+			name:    "error on invalid key",
+			value:   "invalid",
+			wantErr: ErrInvalidSigningKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseSigningKey(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantID, string(got.id[:]))
+			assert.Equal(t, key, got.key)
+		})
+	}
+}
+
+func TestSigningKey_Sign(t *testing.T) {
+	t.Parallel()
+
+	key, err := parseSigningKey(base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize)))
+	assert.NoError(t, err)
+
+	content := []byte("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  a.txt\n")
+	lines := strings.Split(string(key.Sign("SHA256SUMS", content, time.Unix(1700000000, 0))), "\n")
+
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "trusted comment: timestamp:1700000000\tfile:SHA256SUMS", lines[2])
+
+	sig, _ := base64.StdEncoding.DecodeString(lines[1])
+	global, _ := base64.StdEncoding.DecodeString(lines[3])
+	pub, _ := key.key.Public().(ed25519.PublicKey)
+
+	assert.Equal(t, "Ed", string(sig[:2]))
+	assert.Equal(t, key.id[:], sig[2:2+minisignKeyIDLength])
+	assert.True(t, ed25519.Verify(pub, content, sig[2+minisignKeyIDLength:]))
+	assert.True(t, ed25519.Verify(pub, append(sig[2+minisignKeyIDLength:], "timestamp:1700000000\tfile:SHA256SUMS"...), global))
+}
@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// shortCommitLength is the length of the abbreviated commit SHA.
+const shortCommitLength = 8
+
+var ErrUndefinedVariable = errors.New("undefined variable")
+
+// templateFuncs are the helpers available in templated settings. All helpers fail if the
+// underlying Woodpecker variable is not set.
+var templateFuncs = template.FuncMap{
+	"env":    lookupVariable,
+	"commit": func() (string, error) { return lookupVariable("CI_COMMIT_SHA") },
+	"shortCommit": func() (string, error) {
+		sha, err := lookupVariable("CI_COMMIT_SHA")
+
+		return sha[:min(len(sha), shortCommitLength)], err
+	},
+	"branch": func() (string, error) { return lookupVariable("CI_COMMIT_BRANCH") },
+	"tag":    func() (string, error) { return lookupVariable("CI_COMMIT_TAG") },
+	"build":  func() (string, error) { return lookupVariable("CI_PIPELINE_NUMBER") },
+}
+
+func lookupVariable(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: %s", ErrUndefinedVariable, name)
+	}
+
+	return value, nil
+}
+
+// variablePattern matches `${VAR}` references and escaped `$${VAR}` literals.
+var variablePattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// lookupDefined returns the value of a variable that may be empty, but must be set.
+func lookupDefined(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUndefinedVariable, name)
+	}
+
+	return value, nil
+}
+
+// expandTemplate expands `${VAR}` references and Go templates like `{{ commit }}` in the value.
+// Undefined variables result in an error, `$${VAR}` is a literal `${VAR}` and other `$` signs are
+// kept as is. Variables are inserted by the template, their values are never parsed as a template.
+func expandTemplate(value string) (string, error) {
+	if !strings.Contains(value, "${") && !strings.Contains(value, "{{") {
+		return value, nil
+	}
+
+	text := variablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return fmt.Sprintf("{{ %q }}", match[1:])
+		}
+
+		return fmt.Sprintf("{{ variable %q }}", match[2:len(match)-1])
+	})
+
+	funcs := template.FuncMap{"variable": lookupDefined}
+
+	tmpl, err := template.New("").Funcs(templateFuncs).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template '%s': %w", value, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return "", fmt.Errorf("failed to expand template '%s': %w", value, err)
+	}
+
+	return buf.String(), nil
+}
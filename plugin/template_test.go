@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		envs    map[string]string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "static value",
+			value: "builds/latest",
+			want:  "builds/latest",
+		},
+		{
+			name:  "expand variable",
+			value: "builds/${CI_COMMIT_SHA}/",
+			envs:  map[string]string{"CI_COMMIT_SHA": "0123456789abcdef"},
+			want:  "builds/0123456789abcdef/",
+		},
+		{
+			name:  "keep dollar signs",
+			value: "https://example.com/$1?a=$b&c=$$5",
+			want:  "https://example.com/$1?a=$b&c=$$5",
+		},
+		{
+			name:  "escape variable",
+			value: "builds/$${CI_COMMIT_SHA}/${CI_COMMIT_SHA}",
+			envs:  map[string]string{"CI_COMMIT_SHA": "0123456789abcdef"},
+			want:  "builds/${CI_COMMIT_SHA}/0123456789abcdef",
+		},
+		{
+			name:  "variable values are not parsed as template",
+			value: "${CI_COMMIT_MESSAGE}",
+			envs:  map[string]string{"CI_COMMIT_MESSAGE": `{{ env "SECRET_TEST_VARIABLE" }}`, "SECRET_TEST_VARIABLE": "secret"},
+			want:  `{{ env "SECRET_TEST_VARIABLE" }}`,
+		},
+		{
+			name:  "template helpers",
+			value: "{{ branch }}/{{ build }}/{{ shortCommit }}",
+			envs: map[string]string{
+				"CI_COMMIT_SHA":      "0123456789abcdef",
+				"CI_COMMIT_BRANCH":   "main",
+				"CI_PIPELINE_NUMBER": "42",
+			},
+			want: "main/42/01234567",
+		},
+		{
+			name:  "template env helper",
+			value: `{{ env "DEPLOY_ENV" }}`,
+			envs:  map[string]string{"DEPLOY_ENV": "staging"},
+			want:  "staging",
+		},
+		{
+			name:    "error on undefined variable",
+			value:   "builds/${UNDEFINED_TEST_VARIABLE}",
+			wantErr: ErrUndefinedVariable,
+		},
+		{
+			name:    "error on undefined helper variable",
+			value:   "releases/{{ tag }}",
+			envs:    map[string]string{"CI_COMMIT_TAG": ""},
+			wantErr: ErrUndefinedVariable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.envs {
+				t.Setenv(key, value)
+			}
+
+			got, err := expandTemplate(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
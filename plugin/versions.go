@@ -0,0 +1,41 @@
+package plugin
+
+import (
+	"context"
+
+	"github.com/thegeeklab/wp-s3-action/aws"
+)
+
+// touch records a key written or deleted by the sync.
+func (s *syncState) touch(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.touched == nil {
+		s.touched = make(map[string]bool)
+	}
+
+	s.touched[key] = true
+}
+
+// pruneVersions removes noncurrent versions of the touched keys and purges delete markers
+// below the target and the release aliases.
+func (p *Plugin) pruneVersions(ctx context.Context, state *syncState) error {
+	if p.Settings.KeepVersions < 0 && !p.Settings.PurgeDeleteMarkers {
+		return nil
+	}
+
+	for _, prefix := range append([]string{p.Settings.Target}, p.Settings.ReleaseAliases...) {
+		err := state.client.S3.PruneVersions(ctx, aws.S3PruneOptions{
+			Path:               keyPrefix(prefix),
+			Keys:               state.touched,
+			KeepVersions:       p.Settings.KeepVersions,
+			PurgeDeleteMarkers: p.Settings.PurgeDeleteMarkers,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}